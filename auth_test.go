@@ -0,0 +1,156 @@
+package junos_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// newKeyPair returns a new RSA private key, PEM encoded, along with the key and its public key.
+func newKeyPair(t *testing.T) ([]byte, *rsa.PrivateKey, ssh.PublicKey) {
+	t.Helper()
+
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub, err := ssh.NewPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)})
+
+	return data, priv, pub
+}
+
+func TestAuthPrivateKeyData(t *testing.T) {
+	srv := junostest.NewServer()
+	defer srv.Close()
+
+	data, _, pub := newKeyPair(t)
+	srv.AuthorizedKeys = []ssh.PublicKey{pub}
+	srv.DisablePasswordAuth = true
+
+	auth := srv.Auth()
+	auth.Credentials = nil
+	auth.Username = srv.Username
+	auth.PrivateKeyData = data
+
+	j, err := junos.NewSession(srv.Addr, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	other, _, _ := newKeyPair(t)
+	auth.PrivateKeyData = other
+	if _, err := junos.NewSession(srv.Addr, auth); err == nil {
+		t.Error("NewSession authenticated with a key the server doesn't accept")
+	}
+}
+
+func TestAuthKeyboardInteractive(t *testing.T) {
+	srv := junostest.NewServer()
+	defer srv.Close()
+
+	srv.DisablePasswordAuth = true
+
+	// Credentials fall back to keyboard-interactive, answering with the password.
+	j, err := junos.NewSession(srv.Addr, srv.Auth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	asked := 0
+	auth := srv.Auth()
+	auth.Credentials = nil
+	auth.Username = srv.Username
+	auth.KeyboardInteractive = func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+		asked++
+		answers := make([]string, len(questions))
+		for i := range answers {
+			answers[i] = srv.Password
+		}
+
+		return answers, nil
+	}
+
+	j, err = junos.NewSession(srv.Addr, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	if asked != 1 {
+		t.Errorf("the keyboard-interactive challenge was called %d times, want 1", asked)
+	}
+}
+
+func TestAuthAgent(t *testing.T) {
+	srv := junostest.NewServer()
+	defer srv.Close()
+
+	_, priv, pub := newKeyPair(t)
+	srv.AuthorizedKeys = []ssh.PublicKey{pub}
+	srv.DisablePasswordAuth = true
+
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+		t.Fatal(err)
+	}
+
+	sock := filepath.Join(t.TempDir(), "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("can't listen on a unix socket: %v", err)
+	}
+	defer l.Close()
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go agent.ServeAgent(keyring, c)
+		}
+	}()
+
+	old := os.Getenv("SSH_AUTH_SOCK")
+	os.Setenv("SSH_AUTH_SOCK", sock)
+	defer os.Setenv("SSH_AUTH_SOCK", old)
+
+	auth := srv.Auth()
+	auth.Credentials = nil
+	auth.Username = srv.Username
+	auth.UseAgent = true
+
+	j, err := junos.NewSession(srv.Addr, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+}
+
+func TestAuthNoCredentials(t *testing.T) {
+	srv := junostest.NewServer()
+	defer srv.Close()
+
+	if _, err := junos.NewSession(srv.Addr, &junos.AuthMethod{Username: "admin"}); err == nil {
+		t.Error("NewSession succeeded without any credentials or keys")
+	}
+}
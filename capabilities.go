@@ -0,0 +1,116 @@
+package junos
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Common capabilities, for use with Supports. The NETCONF capabilities are given in their
+// short form (e.g. ":candidate"), which matches any version of them.
+const (
+	CapabilityCandidate       = ":candidate"
+	CapabilityConfirmedCommit = ":confirmed-commit"
+	CapabilityValidate        = ":validate"
+	CapabilityURL             = ":url"
+	CapabilityWritableRunning = ":writable-running"
+	CapabilityRollbackOnError = ":rollback-on-error"
+	CapabilityStartup         = ":startup"
+	CapabilityJunos           = "http://xml.juniper.net/netconf/junos/1.0"
+	CapabilityJunosDMI        = "http://xml.juniper.net/dmi/system/1.0"
+)
+
+// Capability is a capability the device advertised in its hello message. URI is the
+// capability itself, without any parameters, which are in Params, e.g. for
+// "urn:ietf:params:netconf:capability:url:1.0?scheme=http,ftp,file", URI is
+// "urn:ietf:params:netconf:capability:url:1.0" and Params is {"scheme": "http,ftp,file"}.
+type Capability struct {
+	URI    string
+	Params map[string]string
+}
+
+// parseCapabilities parses the capabilities the device advertised.
+func parseCapabilities(caps []string) []Capability {
+	res := make([]Capability, 0, len(caps))
+	for _, c := range caps {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+
+		parts := strings.SplitN(c, "?", 2)
+		capability := Capability{URI: parts[0]}
+
+		if len(parts) == 2 {
+			capability.Params = map[string]string{}
+			for _, p := range strings.Split(parts[1], "&") {
+				kv := strings.SplitN(p, "=", 2)
+				if len(kv) == 2 {
+					capability.Params[kv[0]] = kv[1]
+				} else {
+					capability.Params[kv[0]] = ""
+				}
+			}
+		}
+
+		res = append(res, capability)
+	}
+
+	return res
+}
+
+// matches returns whether the capability is the one given, either as its URI, or its short
+// form (e.g. ":candidate").
+func (c Capability) matches(capability string) bool {
+	capability = strings.SplitN(capability, "?", 2)[0]
+
+	if !strings.HasPrefix(capability, ":") {
+		return c.URI == capability
+	}
+
+	// Junos advertises the base capabilities using both the RFC 6241 URNs, and the
+	// (incorrect) namespace-style URNs from RFC 4741's drafts.
+	name := strings.TrimPrefix(capability, ":")
+	for _, prefix := range []string{"urn:ietf:params:netconf:capability:", "urn:ietf:params:xml:ns:netconf:capability:"} {
+		if strings.HasPrefix(c.URI, prefix+name+":") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Supports returns whether the device advertised the given capability in its hello message.
+// The capability is either its URI, e.g. "urn:ietf:params:netconf:capability:candidate:1.0",
+// or its short form, e.g. ":candidate" (see the Capability constants).
+func (j *Junos) Supports(capability string) bool {
+	return j.Capability(capability) != nil
+}
+
+// Capability returns the given capability (see Supports), along with its parameters, or nil
+// if the device didn't advertise it.
+func (j *Junos) Capability(capability string) *Capability {
+	for i := range j.Capabilities {
+		if j.Capabilities[i].matches(capability) {
+			return &j.Capabilities[i]
+		}
+	}
+
+	return nil
+}
+
+// require returns an error if the device doesn't support each of the given capabilities.
+// If we don't know the device's capabilities (e.g. it didn't send a hello), we assume it
+// does.
+func (j *Junos) require(capabilities ...string) error {
+	if len(j.Capabilities) == 0 {
+		return nil
+	}
+
+	for _, c := range capabilities {
+		if !j.Supports(c) {
+			return fmt.Errorf("%s does not support the %s capability", j.Hostname, c)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package junos_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scottdware/go-junos"
+)
+
+func TestSupports(t *testing.T) {
+	j, _ := newSession(t)
+
+	for _, c := range []string{junos.CapabilityCandidate, junos.CapabilityJunos, "urn:ietf:params:netconf:capability:url:1.0"} {
+		if !j.Supports(c) {
+			t.Errorf("the session doesn't support %s", c)
+		}
+	}
+
+	if j.Supports(junos.CapabilityWritableRunning) {
+		t.Errorf("the session supports %s, which the server didn't advertise", junos.CapabilityWritableRunning)
+	}
+
+	c := j.Capability(junos.CapabilityURL)
+	if c == nil || c.Params["scheme"] != "http,ftp,file" {
+		t.Errorf("got capability %+v", c)
+	}
+}
+
+func TestRequireCapability(t *testing.T) {
+	srv := newServer(t)
+	srv.Capabilities = []string{"urn:ietf:params:netconf:base:1.0"}
+
+	j, err := junos.NewSession(srv.Addr, srv.Auth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+	srv.Reset()
+
+	if err := j.Commit(); err == nil || !strings.Contains(err.Error(), "does not support the :candidate capability") {
+		t.Errorf("Commit returned %v", err)
+	}
+
+	if err := j.Lock(); err == nil {
+		t.Error("Lock succeeded without the :candidate capability")
+	}
+
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("%d RPCs were sent that the device doesn't support", n)
+	}
+}
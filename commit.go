@@ -0,0 +1,190 @@
+package junos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CommitOptions controls how the configuration is committed. Every commit method is a
+// shortcut for CommitWithOptions, e.g. CommitAt("23:00", "maintenance") is the same as:
+//
+//	j.CommitWithOptions(ctx, &CommitOptions{At: "23:00", Comment: "maintenance"})
+//
+// Comment is the log message for the commit. If Confirmed is more than zero, the commit is
+// rolled back after that many minutes, unless it's confirmed (see ConfirmCommit). At is the
+// time to commit at, in 24-hour HH:mm format (or "YYYY-MM-DD HH:mm:ss"). Synchronize also
+// commits the configuration on the other routing engine, and ForceSynchronize does that even
+// if the other routing engine's configuration is locked, or has uncommitted changes. Check
+// only checks the configuration, without committing it. Full makes every daemon check and
+// re-read the whole configuration, not just the parts that changed.
+type CommitOptions struct {
+	Comment          string
+	Confirmed        int
+	At               string
+	Synchronize      bool
+	ForceSynchronize bool
+	Check            bool
+	Full             bool
+}
+
+// confirmedRegex matches the comment Junos adds to a confirmed commit that's pending, e.g.
+// "commit confirmed, rollback in 5mins".
+var confirmedRegex = regexp.MustCompile(`rollback in (\d+)\s*min`)
+
+// junosTime is a date-time element in a reply. Seconds is its junos:seconds attribute, the
+// time since the epoch, which (unlike the text) doesn't depend on the device's time zone.
+type junosTime struct {
+	Seconds string `xml:"seconds,attr"`
+}
+
+// time returns the time the element is for.
+func (t junosTime) time() (time.Time, error) {
+	secs, err := strconv.ParseInt(strings.TrimSpace(t.Seconds), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing the device's time %q - %s", t.Seconds, err)
+	}
+
+	return time.Unix(secs, 0), nil
+}
+
+// CommitWithOptions commits the configuration (see CommitOptions), and returns the warnings
+// the device reported, which don't fail the commit unless WarningPolicy is FatalWarnings.
+// Passing nil options is the same as Commit.
+func (j *Junos) CommitWithOptions(ctx context.Context, opts *CommitOptions) (*CommitResult, error) {
+	if opts == nil {
+		opts = &CommitOptions{}
+	}
+
+	command, err := opts.command()
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Confirmed > 0 {
+		if err := j.require(CapabilityConfirmedCommit); err != nil {
+			return nil, err
+		}
+	}
+
+	return j.commit(ctx, command)
+}
+
+// command returns the commit-configuration RPC for the options.
+func (o *CommitOptions) command() (string, error) {
+	switch {
+	case o.Confirmed < 0:
+		return "", fmt.Errorf("invalid confirmed commit timeout %d", o.Confirmed)
+	case o.Check && (o.Confirmed > 0 || o.At != ""):
+		return "", errors.New("a commit check can't be confirmed, or done at a later time")
+	case o.Confirmed > 0 && o.At != "":
+		return "", errors.New("a confirmed commit can't be done at a later time")
+	}
+
+	var b strings.Builder
+	b.WriteString("<commit-configuration>")
+
+	if o.Check {
+		b.WriteString("<check/>")
+	}
+	if o.Synchronize || o.ForceSynchronize {
+		b.WriteString("<synchronize/>")
+	}
+	if o.ForceSynchronize {
+		b.WriteString("<force-synchronize/>")
+	}
+	if o.Full {
+		b.WriteString("<full/>")
+	}
+	if o.Confirmed > 0 {
+		fmt.Fprintf(&b, "<confirmed/><confirm-timeout>%d</confirm-timeout>", o.Confirmed)
+	}
+	if o.At != "" {
+		fmt.Fprintf(&b, "<at-time>%s</at-time>", xmlEscape(o.At))
+	}
+	if o.Comment != "" {
+		fmt.Fprintf(&b, "<log>%s</log>", xmlEscape(o.Comment))
+	}
+
+	b.WriteString("</commit-configuration>")
+
+	return b.String(), nil
+}
+
+// ConfirmCommit confirms the pending confirmed commit (see CommitConfirm), so it isn't rolled
+// back, and returns how much time was left before it would have been. An error is returned,
+// and nothing is committed, if there isn't a confirmed commit pending, or the time left can't
+// be worked out from the device's replies.
+func (j *Junos) ConfirmCommit() (time.Duration, error) {
+	return j.ConfirmCommitContext(context.Background())
+}
+
+// ConfirmCommitContext is the same as ConfirmCommit, but stops waiting on the device once the
+// given context is done.
+func (j *Junos) ConfirmCommitContext(ctx context.Context) (time.Duration, error) {
+	left, err := j.confirmTimeLeft(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := j.CommitWithOptions(ctx, nil); err != nil {
+		return 0, err
+	}
+
+	return left, nil
+}
+
+// confirmTimeLeft returns how long is left before the pending confirmed commit is rolled
+// back, using the device's own clock.
+func (j *Junos) confirmTimeLeft(ctx context.Context) (time.Duration, error) {
+	var history struct {
+		Entries []struct {
+			Log      string    `xml:"log"`
+			Comment  string    `xml:"comment"`
+			DateTime junosTime `xml:"date-time"`
+		} `xml:"commit-history"`
+	}
+	if err := j.RPCContext(ctx, rpcCommitHistory, &history); err != nil {
+		return 0, err
+	}
+
+	if len(history.Entries) == 0 {
+		return 0, errors.New("there is no confirmed commit pending")
+	}
+
+	last := history.Entries[0]
+	m := confirmedRegex.FindStringSubmatch(last.Comment + " " + last.Log)
+	if m == nil {
+		return 0, errors.New("there is no confirmed commit pending")
+	}
+
+	mins, _ := strconv.Atoi(m[1])
+
+	committed, err := last.DateTime.time()
+	if err != nil {
+		return 0, err
+	}
+
+	var uptime struct {
+		Now junosTime `xml:"current-time>date-time"`
+	}
+	if err := j.RPCContext(ctx, rpcPing, &uptime); err != nil {
+		return 0, err
+	}
+
+	now, err := uptime.Now.time()
+	if err != nil {
+		return 0, err
+	}
+
+	left := committed.Add(time.Duration(mins) * time.Minute).Sub(now)
+	if left < 0 {
+		left = 0
+	}
+
+	return left, nil
+}
@@ -0,0 +1,174 @@
+package junos_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+// lastRequest returns the XML of the last RPC the server received.
+func lastRequest(t *testing.T, srv *junostest.Server) string {
+	t.Helper()
+
+	reqs := srv.Requests()
+	if len(reqs) == 0 {
+		t.Fatal("the server didn't receive any RPCs")
+	}
+
+	return reqs[len(reqs)-1].XML
+}
+
+func TestCommitWithOptions(t *testing.T) {
+	j, srv := newSession(t)
+
+	opts := &junos.CommitOptions{Comment: "a<b", ForceSynchronize: true, Full: true}
+	if _, err := j.CommitWithOptions(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	rpc := lastRequest(t, srv)
+	for _, want := range []string{"<synchronize", "<force-synchronize", "<full", "<log>a&lt;b</log>"} {
+		if !strings.Contains(rpc, want) {
+			t.Errorf("commit %s doesn't contain %s", rpc, want)
+		}
+	}
+}
+
+func TestCommitHelpers(t *testing.T) {
+	j, srv := newSession(t)
+
+	tests := []struct {
+		name   string
+		commit func() error
+		want   []string
+	}{
+		{"CommitAt", func() error { return j.CommitAt("23:00", "maint") }, []string{"<at-time>23:00</at-time>", "<log>maint</log>"}},
+		{"CommitConfirm", func() error { return j.CommitConfirm(3) }, []string{"<confirmed", "<confirm-timeout>3</confirm-timeout>"}},
+		{"CommitCheck", j.CommitCheck, []string{"<check"}},
+	}
+
+	for _, tt := range tests {
+		if err := tt.commit(); err != nil {
+			t.Errorf("%s returned %v", tt.name, err)
+			continue
+		}
+
+		rpc := lastRequest(t, srv)
+		for _, want := range tt.want {
+			if !strings.Contains(rpc, want) {
+				t.Errorf("%s sent %s, which doesn't contain %s", tt.name, rpc, want)
+			}
+		}
+	}
+}
+
+func TestCommitInvalidOptions(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Reset()
+
+	for _, opts := range []*junos.CommitOptions{
+		{Confirmed: -1},
+		{Check: true, At: "23:00"},
+		{Confirmed: 1, At: "23:00"},
+	} {
+		if _, err := j.CommitWithOptions(context.Background(), opts); err == nil {
+			t.Errorf("CommitWithOptions(%+v) didn't return an error", opts)
+		}
+	}
+
+	if reqs := srv.Requests(); len(reqs) != 0 {
+		t.Errorf("invalid options sent %d RPCs", len(reqs))
+	}
+}
+
+func TestConfirmCommit(t *testing.T) {
+	j, srv := newSession(t)
+
+	// Committed at 12:30:01, with 5 minutes to confirm it, and the server's clock is 12:34:53.
+	srv.Handle("get-commit-information", `<commit-information>
+<commit-history>
+<sequence-number>0</sequence-number>
+<user>admin</user>
+<client>netconf</client>
+<date-time junos:seconds="1571142601">2019-10-15 12:30:01 UTC</date-time>
+<comment>commit confirmed, rollback in 5mins</comment>
+</commit-history>
+</commit-information>`)
+	srv.Reset()
+
+	left, err := j.ConfirmCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if left != 8*time.Second {
+		t.Errorf("got %s left, want 8s", left)
+	}
+
+	if n := srv.Received("commit-configuration"); n != 1 {
+		t.Errorf("got %d commits, want 1", n)
+	}
+}
+
+func TestConfirmCommitTimeZone(t *testing.T) {
+	j, srv := newSession(t)
+
+	// The same times as TestConfirmCommit, on a device in another time zone.
+	srv.Handle("get-commit-information", `<commit-information>
+<commit-history>
+<sequence-number>0</sequence-number>
+<date-time junos:seconds="1571142601">2019-10-15 05:30:01 PDT</date-time>
+<comment>commit confirmed, rollback in 5mins</comment>
+</commit-history>
+</commit-information>`)
+	srv.Handle("get-system-uptime-information", `<system-uptime-information>
+<current-time><date-time junos:seconds="1571142893">2019-10-15 05:34:53 PDT</date-time></current-time>
+</system-uptime-information>`)
+
+	left, err := j.ConfirmCommit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if left != 8*time.Second {
+		t.Errorf("got %s left, want 8s", left)
+	}
+}
+
+func TestConfirmCommitNoTime(t *testing.T) {
+	j, srv := newSession(t)
+
+	srv.Handle("get-commit-information", `<commit-information>
+<commit-history>
+<sequence-number>0</sequence-number>
+<date-time>2019-10-15 12:30:01 UTC</date-time>
+<comment>commit confirmed, rollback in 5mins</comment>
+</commit-history>
+</commit-information>`)
+	srv.Reset()
+
+	if _, err := j.ConfirmCommit(); err == nil {
+		t.Error("ConfirmCommit didn't return an error when the commit time couldn't be parsed")
+	}
+
+	if n := srv.Received("commit-configuration"); n != 0 {
+		t.Errorf("got %d commits, want 0", n)
+	}
+}
+
+func TestConfirmCommitNotPending(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Reset()
+
+	if _, err := j.ConfirmCommit(); err == nil {
+		t.Error("ConfirmCommit didn't return an error without a confirmed commit pending")
+	}
+
+	if n := srv.Received("commit-configuration"); n != 0 {
+		t.Errorf("got %d commits, want 0", n)
+	}
+}
@@ -0,0 +1,263 @@
+package junos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Juniper/go-netconf/netconf"
+)
+
+// ConfigMode is the configuration database a ConfigSession works on.
+type ConfigMode int
+
+const (
+	// ConfigPrivate works on a private copy of the candidate configuration, the same as
+	// "configure private". Only the changes made in it are committed, and they're discarded
+	// if they aren't committed before the session is closed.
+	ConfigPrivate ConfigMode = iota + 1
+
+	// ConfigExclusive locks the shared candidate configuration, the same as "configure
+	// exclusive", so nobody else can change it. Uncommitted changes are discarded when the
+	// session is closed.
+	ConfigExclusive
+
+	// ConfigEphemeral works on an ephemeral configuration database, which is layered on top
+	// of the committed configuration, and is committed without the usual (slow) commit
+	// checks. Either the default instance, or a named one, can be opened.
+	ConfigEphemeral
+)
+
+// errSessionReplaced is returned when an RPC for a configuration database is sent after the
+// session it was opened on has failed, or been re-established.
+var errSessionReplaced = errors.New("the session the configuration database was opened on has closed")
+
+var (
+	rpcOpenPrivate          = "<open-configuration><private/></open-configuration>"
+	rpcOpenEphemeral        = "<open-configuration><ephemeral/></open-configuration>"
+	rpcOpenEphemeralNamed   = "<open-configuration><ephemeral-instance>%s</ephemeral-instance></open-configuration>"
+	rpcCloseConfiguration   = "<close-configuration/>"
+	rpcDiscardConfiguration = "<load-configuration rollback=\"0\"/>"
+)
+
+// ConfigSession is an open configuration database (see ConfigMode). While it's open, every
+// configuration change on the session is made in that database, including those made with
+// Config and Commit, so they don't collide with anyone else's. It must be closed when you're
+// done with it; WithConfigSession does that for you.
+type ConfigSession struct {
+	j       *Junos
+	mode    ConfigMode
+	session *netconf.Session
+}
+
+// OpenConfigSession opens the given configuration database. For ConfigEphemeral, the name of
+// the ephemeral instance can be given; if it isn't, the default instance is opened. Only one
+// configuration database can be open on a session at a time.
+func (j *Junos) OpenConfigSession(ctx context.Context, mode ConfigMode, instance ...string) (*ConfigSession, error) {
+	if err := j.require(CapabilityCandidate); err != nil {
+		return nil, err
+	}
+
+	cs := &ConfigSession{j: j, mode: mode}
+
+	var command string
+	switch mode {
+	case ConfigPrivate:
+		command = rpcOpenPrivate
+	case ConfigExclusive:
+		command = rpcLock
+	case ConfigEphemeral:
+		command = rpcOpenEphemeral
+		if len(instance) > 0 && strings.TrimSpace(instance[0]) != "" {
+			command = fmt.Sprintf(rpcOpenEphemeralNamed, xmlEscape(strings.TrimSpace(instance[0])))
+		}
+	default:
+		return nil, fmt.Errorf("unknown configuration mode %d", mode)
+	}
+
+	j.configMu.Lock()
+	defer j.configMu.Unlock()
+
+	if j.configSession != nil {
+		return nil, errors.New("a configuration database is already open on this session")
+	}
+
+	err := j.retry(ctx, func() error {
+		return j.runOK(ctx, command)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	j.connMu.Lock()
+	cs.session = j.Session
+	j.connMu.Unlock()
+
+	j.configSession = cs
+
+	return cs, nil
+}
+
+// WithConfigSession opens the given configuration database (see OpenConfigSession), and
+// calls fn with it. The database is always closed once fn returns, even if it returns an
+// error or panics. If fn succeeds, the error from closing the database is returned.
+func (j *Junos) WithConfigSession(ctx context.Context, mode ConfigMode, fn func(cs *ConfigSession) error, instance ...string) (err error) {
+	cs, err := j.OpenConfigSession(ctx, mode, instance...)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// Close even if ctx is done, so the database isn't left open.
+		if cerr := cs.Close(context.Background()); err == nil {
+			err = cerr
+		}
+	}()
+
+	return fn(cs)
+}
+
+// Mode returns the configuration database the session works on.
+func (cs *ConfigSession) Mode() ConfigMode {
+	return cs.mode
+}
+
+// Load loads a configuration into the database (see LoadOptions), without committing it.
+func (cs *ConfigSession) Load(ctx context.Context, opts *LoadOptions) (*LoadResult, error) {
+	if err := cs.check(); err != nil {
+		return nil, err
+	}
+
+	return cs.j.Load(ctx, opts)
+}
+
+// Diff returns the uncommitted changes in the database.
+func (cs *ConfigSession) Diff(ctx context.Context) (string, error) {
+	if err := cs.check(); err != nil {
+		return "", err
+	}
+
+	return cs.j.DiffContext(ctx, 0)
+}
+
+// CommitCheck validates the changes in the database, without committing them.
+func (cs *ConfigSession) CommitCheck(ctx context.Context) (*CommitResult, error) {
+	if err := cs.check(); err != nil {
+		return nil, err
+	}
+
+	return cs.CommitWithOptions(ctx, &CommitOptions{Check: true})
+}
+
+// Commit commits the changes in the database.
+func (cs *ConfigSession) Commit(ctx context.Context) (*CommitResult, error) {
+	if err := cs.check(); err != nil {
+		return nil, err
+	}
+
+	return cs.CommitWithOptions(ctx, nil)
+}
+
+// CommitWithOptions commits the changes in the database (see CommitOptions).
+func (cs *ConfigSession) CommitWithOptions(ctx context.Context, opts *CommitOptions) (*CommitResult, error) {
+	if err := cs.check(); err != nil {
+		return nil, err
+	}
+
+	return cs.j.CommitWithOptions(ctx, opts)
+}
+
+// CommitConfirm commits the changes in the database, and rolls them back after the given
+// number of minutes, unless they're confirmed by committing again (see CommitConfirm).
+func (cs *ConfigSession) CommitConfirm(ctx context.Context, delay int) (*CommitResult, error) {
+	if err := cs.check(); err != nil {
+		return nil, err
+	}
+
+	return cs.CommitWithOptions(ctx, &CommitOptions{Confirmed: delay})
+}
+
+// Close closes the database, discarding any uncommitted changes. For ConfigExclusive, the
+// candidate configuration is unlocked. Closing a database that's already closed does nothing.
+// If the session has failed, the device closes the database along with it, so nothing is
+// sent; in particular, the discard and unlock are never sent on a re-established session,
+// where they'd throw away other people's changes.
+func (cs *ConfigSession) Close(ctx context.Context) error {
+	j := cs.j
+
+	j.configMu.Lock()
+	defer j.configMu.Unlock()
+
+	if j.configSession != cs {
+		return nil
+	}
+	j.configSession = nil
+
+	var err error
+	if cs.mode == ConfigExclusive {
+		err = cs.runOK(ctx, rpcDiscardConfiguration)
+		if err != errSessionReplaced {
+			if uerr := cs.runOK(ctx, rpcUnlock); err == nil {
+				err = uerr
+			}
+		}
+	} else {
+		err = cs.runOK(ctx, rpcCloseConfiguration)
+	}
+
+	if err == errSessionReplaced {
+		return nil
+	}
+
+	return err
+}
+
+// runOK runs an RPC that replies with <ok/> on the session the database was opened on. If
+// that session has failed, or been re-established, errSessionReplaced is returned.
+func (cs *ConfigSession) runOK(ctx context.Context, command string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := cs.j.throttle(ctx); err != nil {
+		return err
+	}
+
+	reply, err := cs.j.execOn(ctx, cs.session, command)
+	if err != nil {
+		return err
+	}
+
+	return cs.j.replyError(reply)
+}
+
+// check returns an error if the database has been closed, including by the session being
+// re-established (see EnableReconnect), which loses any changes made in it.
+func (cs *ConfigSession) check() error {
+	j := cs.j
+
+	j.configMu.Lock()
+	open := j.configSession == cs
+	j.configMu.Unlock()
+
+	j.connMu.Lock()
+	same := j.Session == cs.session
+	j.connMu.Unlock()
+
+	if !open || !same {
+		return errors.New("the configuration database has been closed")
+	}
+
+	return nil
+}
+
+// runOK runs an RPC that replies with <ok/>, and returns any errors the device reported.
+func (j *Junos) runOK(ctx context.Context, command string) error {
+	reply, err := j.exec(ctx, command)
+	if err != nil {
+		return err
+	}
+
+	return j.replyError(reply)
+}
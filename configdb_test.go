@@ -0,0 +1,167 @@
+package junos_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scottdware/go-junos"
+)
+
+func TestConfigSessionPrivate(t *testing.T) {
+	j, srv := newSession(t)
+	ctx := context.Background()
+	srv.Reset()
+
+	err := j.WithConfigSession(ctx, junos.ConfigPrivate, func(cs *junos.ConfigSession) error {
+		if _, err := j.OpenConfigSession(ctx, junos.ConfigExclusive); err == nil {
+			t.Error("a second configuration database was opened on the session")
+		}
+
+		if _, err := cs.Load(ctx, &junos.LoadOptions{Format: "set", Lines: []string{"set system host-name fw2"}}); err != nil {
+			return err
+		}
+
+		diff, err := cs.Diff(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !strings.Contains(diff, "host-name fw2") {
+			t.Errorf("got diff %q", diff)
+		}
+
+		_, err = cs.Commit(ctx)
+
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) == 0 || reqs[0].Name != "open-configuration" || !strings.Contains(reqs[0].XML, "<private/>") {
+		t.Errorf("the private database wasn't opened first: %+v", reqs)
+	}
+
+	if srv.Received("open-configuration") != 1 || srv.Received("close-configuration") != 1 {
+		t.Errorf("got requests %+v", reqs)
+	}
+}
+
+func TestConfigSessionExclusive(t *testing.T) {
+	j, srv := newSession(t)
+	ctx := context.Background()
+	srv.Reset()
+
+	failed := errors.New("failed")
+	err := j.WithConfigSession(ctx, junos.ConfigExclusive, func(*junos.ConfigSession) error {
+		return failed
+	})
+	if err != failed {
+		t.Errorf("WithConfigSession returned %v, want %v", err, failed)
+	}
+
+	// The changes are rolled back (by loading rollback 0) before the lock is released.
+	if srv.Received("lock-configuration") != 1 || srv.Received("load-configuration") != 1 || srv.Received("unlock-configuration") != 1 {
+		t.Errorf("got requests %+v", srv.Requests())
+	}
+}
+
+func TestConfigSessionEphemeral(t *testing.T) {
+	j, srv := newSession(t)
+	ctx := context.Background()
+	srv.Reset()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("WithConfigSession didn't re-panic")
+			}
+		}()
+
+		j.WithConfigSession(ctx, junos.ConfigEphemeral, func(*junos.ConfigSession) error {
+			panic("failed")
+		}, "tools&co")
+	}()
+
+	reqs := srv.Requests()
+	if len(reqs) == 0 || !strings.Contains(reqs[0].XML, "<ephemeral-instance>tools&amp;co</ephemeral-instance>") {
+		t.Errorf("the ephemeral instance wasn't opened: %+v", reqs)
+	}
+
+	if n := srv.Received("close-configuration"); n != 1 {
+		t.Errorf("the database was closed %d times after a panic, want 1", n)
+	}
+}
+
+func TestConfigSessionClose(t *testing.T) {
+	j, _ := newSession(t)
+	ctx := context.Background()
+
+	cs, err := j.OpenConfigSession(ctx, junos.ConfigPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cs.Mode() != junos.ConfigPrivate {
+		t.Errorf("got mode %v", cs.Mode())
+	}
+
+	if err := cs.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cs.Close(ctx); err != nil {
+		t.Errorf("closing the database again returned %v", err)
+	}
+
+	if _, err := cs.Commit(ctx); err == nil {
+		t.Error("Commit succeeded on a closed database")
+	}
+
+	cs, err = j.OpenConfigSession(ctx, junos.ConfigExclusive)
+	if err != nil {
+		t.Fatalf("the database couldn't be opened again after it was closed - %s", err)
+	}
+	cs.Close(ctx)
+}
+
+func TestConfigSessionCloseAfterFailure(t *testing.T) {
+	j, srv := newSession(t)
+	ctx := context.Background()
+
+	if err := j.EnableReconnect(&junos.ReconnectOptions{MinBackoff: 10 * time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+
+	cs, err := j.OpenConfigSession(ctx, junos.ConfigExclusive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fail the transport, without re-establishing the session.
+	srv.CloseClientConnections()
+	for i := 0; j.Ping() == nil; i++ {
+		if i == 10 {
+			t.Fatal("Ping kept succeeding on a dropped connection")
+		}
+	}
+	srv.Reset()
+
+	if err := cs.Close(ctx); err != nil {
+		t.Errorf("Close returned %v", err)
+	}
+
+	// The rollback would discard everyone else's changes on the new session, and it never
+	// held the lock.
+	if reqs := srv.Requests(); len(reqs) != 0 {
+		t.Errorf("Close sent %+v after the session failed", reqs)
+	}
+
+	if err := j.Ping(); err != nil {
+		t.Errorf("Ping after closing the database returned %v", err)
+	}
+}
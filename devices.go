@@ -1,6 +1,7 @@
 package junos
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"regexp"
@@ -58,11 +59,11 @@ var addDeviceHostXML = `
 `
 
 // getDeviceID returns the ID of a managed device.
-func (s *Space) getDeviceID(device interface{}) (int, error) {
+func (s *Space) getDeviceID(ctx context.Context, device interface{}) (int, error) {
 	var err error
 	var deviceID int
 	ipRegex := regexp.MustCompile(`(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3})`)
-	devices, err := s.Devices()
+	devices, err := s.DevicesContext(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -90,6 +91,12 @@ func (s *Space) getDeviceID(device interface{}) (int, error) {
 
 // AddDevice adds a new managed device to Junos Space, and returns the Job ID.
 func (s *Space) AddDevice(host, user, password string) (int, error) {
+	return s.AddDeviceContext(context.Background(), host, user, password)
+}
+
+// AddDeviceContext is the same as AddDevice, but cancels the request to the server once
+// the given context is done.
+func (s *Space) AddDeviceContext(ctx context.Context, host, user, password string) (int, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{
@@ -108,7 +115,7 @@ func (s *Space) AddDevice(host, user, password string) (int, error) {
 	uri := fmt.Sprintf("https://%s/api/space/device-management/discover-devices", s.Host)
 	xmlBody = fmt.Sprintf(addDevice, host, user, password)
 
-	resp := r.Send("post", uri, []byte(xmlBody), headers, nil)
+	resp := s.send(ctx, r, "post", uri, []byte(xmlBody), headers, nil)
 	if resp.Error != nil {
 		return 0, resp.Error
 	}
@@ -124,12 +131,18 @@ func (s *Space) AddDevice(host, user, password string) (int, error) {
 // Devices queries the Junos Space server and returns all of the information
 // about each device that is managed by Space.
 func (s *Space) Devices() (*Devices, error) {
+	return s.DevicesContext(context.Background())
+}
+
+// DevicesContext is the same as Devices, but cancels the request to the server once the
+// given context is done.
+func (s *Space) DevicesContext(ctx context.Context) (*Devices, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	var devices Devices
 	uri := fmt.Sprintf("https://%s/api/space/device-management/devices", s.Host)
 
-	resp := r.Send("get", uri, nil, nil, nil)
+	resp := s.send(ctx, r, "get", uri, nil, nil, nil)
 	if resp.Error != nil {
 		return nil, resp.Error
 	}
@@ -145,10 +158,16 @@ func (s *Space) Devices() (*Devices, error) {
 // RemoveDevice removes a device from Junos Space. You can specify the device ID, name
 // or IP address.
 func (s *Space) RemoveDevice(device interface{}) error {
+	return s.RemoveDeviceContext(context.Background(), device)
+}
+
+// RemoveDeviceContext is the same as RemoveDevice, but cancels the request to the server
+// once the given context is done.
+func (s *Space) RemoveDeviceContext(ctx context.Context, device interface{}) error {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	var err error
-	deviceID, err := s.getDeviceID(device)
+	deviceID, err := s.getDeviceID(ctx, device)
 	if err != nil {
 		return err
 	}
@@ -156,7 +175,7 @@ func (s *Space) RemoveDevice(device interface{}) error {
 	if deviceID != 0 {
 		uri := fmt.Sprintf("https://%s/api/space/device-management/devices/%d", s.Host, deviceID)
 
-		resp := r.Send("delete", uri, nil, nil, nil)
+		resp := s.send(ctx, r, "delete", uri, nil, nil, nil)
 		if resp.Error != nil {
 			return resp.Error
 		}
@@ -168,20 +187,26 @@ func (s *Space) RemoveDevice(device interface{}) error {
 // Resync synchronizes the device with Junos Space. Good to use if you make a lot of
 // changes outside of Junos Space such as adding interfaces, zones, etc.
 func (s *Space) Resync(device interface{}) (int, error) {
+	return s.ResyncContext(context.Background(), device)
+}
+
+// ResyncContext is the same as Resync, but cancels the request to the server once the
+// given context is done.
+func (s *Space) ResyncContext(ctx context.Context, device interface{}) (int, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{
 		"Content-Type": contentResync,
 	}
 	var job jobID
-	deviceID, err := s.getDeviceID(device)
+	deviceID, err := s.getDeviceID(ctx, device)
 	if err != nil {
 		return 0, err
 	}
 
 	uri := fmt.Sprintf("https://%s/api/space/device-management/devices/%d/exec-resync", s.Host, deviceID)
 
-	resp := r.Send("post", uri, nil, headers, nil)
+	resp := s.send(ctx, r, "post", uri, nil, headers, nil)
 	if resp.Error != nil {
 		return 0, resp.Error
 	}
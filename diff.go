@@ -0,0 +1,690 @@
+package junos
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DiffOp is what happened to a statement in a configuration diff.
+type DiffOp string
+
+// The operations in a configuration diff.
+const (
+	// DiffAdd is a statement that was added.
+	DiffAdd DiffOp = "add"
+
+	// DiffDelete is a statement that was deleted.
+	DiffDelete DiffOp = "delete"
+
+	// DiffChange is a statement whose value changed, e.g. the host-name.
+	DiffChange DiffOp = "change"
+)
+
+// DiffEntry is a single statement in a configuration diff. Path is the hierarchy of the
+// statement, including the statement itself, e.g. []string{"system", "host-name"}. Old and
+// New are the statement's value before and after the change; statements without a value
+// (e.g. "disable") have neither.
+type DiffEntry struct {
+	Op   DiffOp   `json:"op"`
+	Path []string `json:"path"`
+	Old  string   `json:"old,omitempty"`
+	New  string   `json:"new,omitempty"`
+}
+
+// ConfigDiff is a structured configuration diff, which can be rendered as text (Unified),
+// set commands (Set) or JSON (JSON). Entries are in the order they appear in the diff.
+type ConfigDiff struct {
+	Entries []*DiffEntry
+}
+
+// flattenedLists maps the configuration elements whose list entries don't include the name
+// of the list element in text and set formats, e.g. "set interfaces ge-0/0/0" rather than
+// "set interfaces interface ge-0/0/0".
+var flattenedLists = map[string]string{
+	"interfaces":        "interface",
+	"vlans":             "vlan",
+	"routing-instances": "instance",
+	"bridge-domains":    "domain",
+	"prefix-list":       "prefix-list-item",
+}
+
+// StructuredDiff is the same as Diff, but returns the changes as a structured diff rather
+// than the text of "show | compare".
+func (j *Junos) StructuredDiff(rollback int) (*ConfigDiff, error) {
+	return j.StructuredDiffContext(context.Background(), rollback)
+}
+
+// StructuredDiffContext is the same as StructuredDiff, but stops waiting on the device once
+// the given context is done.
+func (j *Junos) StructuredDiffContext(ctx context.Context, rollback int) (*ConfigDiff, error) {
+	text, err := j.DiffContext(ctx, rollback)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseDiff(text)
+}
+
+// ParseDiff parses the text output of "show | compare" (i.e. Diff) into a structured diff. A
+// statement that's deleted and added again with a different value, e.g. the host-name, is
+// returned as a single change. The text doesn't say which statements can have more than one
+// value, so a list entry replaced by another (e.g. an address) is returned as a change too;
+// DiffXML doesn't have that problem.
+func ParseDiff(text string) (*ConfigDiff, error) {
+	d := &ConfigDiff{}
+
+	var base []string
+	var stack [][]string
+
+	// Statements in a list of values, which can't be changes.
+	lists := make(map[*DiffEntry]bool)
+
+	// Whether the line is in an annotation that's split over several lines.
+	annotation := false
+
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		trimmed := strings.TrimSpace(line)
+
+		if annotation {
+			annotation = !strings.Contains(line, "*/")
+			continue
+		}
+
+		switch {
+		case trimmed == "", trimmed == "...", strings.HasPrefix(trimmed, "#"):
+			continue
+		case strings.HasPrefix(trimmed, "[edit") && strings.HasSuffix(trimmed, "]"):
+			words, _, err := diffWords(strings.TrimSuffix(strings.TrimPrefix(trimmed, "[edit"), "]"))
+			if err != nil {
+				return nil, fmt.Errorf("error parsing diff line %d - %s", n+1, err)
+			}
+
+			base = words
+			stack = nil
+
+			continue
+		}
+
+		op := DiffOp("")
+		switch line[0] {
+		case '+':
+			op = DiffAdd
+		case '-':
+			op = DiffDelete
+		}
+
+		content := strings.TrimSpace(line[1:])
+		if op == "" && line[0] != '!' {
+			content = trimmed
+		}
+
+		if strings.HasPrefix(content, "/*") {
+			annotation = !strings.Contains(content[2:], "*/")
+			continue
+		}
+
+		if strings.HasPrefix(content, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(content, "}") {
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("error parsing diff line %d - unexpected }", n+1)
+			}
+			stack = stack[:len(stack)-1]
+
+			continue
+		}
+
+		words, end, err := diffWords(content)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing diff line %d - %s", n+1, err)
+		}
+
+		if len(words) > 0 && (words[0] == "inactive:" || words[0] == "protect:") {
+			words = words[1:]
+		}
+
+		if len(words) == 0 {
+			continue
+		}
+
+		if end == '{' {
+			// Blocks shown only for context are collapsed onto one line, e.g. "ge-0/0/2 { ... }".
+			if !strings.HasSuffix(content, "}") {
+				stack = append(stack, words)
+			}
+
+			continue
+		}
+
+		if op == "" {
+			continue
+		}
+
+		path := append([]string{}, base...)
+		for _, s := range stack {
+			path = append(path, s...)
+		}
+
+		stmts := expandLists(words)
+		for _, stmt := range stmts {
+			e := &DiffEntry{Op: op}
+
+			e.Path = append(append([]string{}, path...), stmt[0])
+			value := ""
+			if len(stmt) > 1 {
+				e.Path = append(append([]string{}, path...), stmt[:len(stmt)-1]...)
+				value = stmt[len(stmt)-1]
+			}
+
+			if op == DiffAdd {
+				e.New = value
+			} else {
+				e.Old = value
+			}
+
+			lists[e] = len(stmts) > 1 || strings.Contains(content, "[")
+			d.Entries = append(d.Entries, e)
+		}
+	}
+
+	if len(stack) > 0 {
+		return nil, errors.New("error parsing diff - missing }")
+	}
+
+	d.pair(lists)
+
+	return d, nil
+}
+
+// diffWords splits a configuration statement into its words, up to the ; or { that ends
+// it, which is returned as well. Quoted strings are unquoted, and the [ and ] around lists
+// of values are returned as words.
+func diffWords(s string) ([]string, byte, error) {
+	var words []string
+
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == ';' || c == '{':
+			return words, c, nil
+		case c == '"':
+			var b strings.Builder
+
+			i++
+			for i < len(s) && s[i] != '"' {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+				i++
+			}
+
+			if i >= len(s) {
+				return nil, 0, errors.New("unterminated quoted string")
+			}
+			i++
+
+			words = append(words, b.String())
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t;{\"", rune(s[i])) {
+				i++
+			}
+
+			words = append(words, s[start:i])
+		}
+	}
+
+	return words, 0, nil
+}
+
+// expandLists splits a statement with a list of values, e.g. "members [ v10 v20 ]", into a
+// statement for each value.
+func expandLists(words []string) [][]string {
+	open := -1
+	for i, w := range words {
+		if w == "[" {
+			open = i
+			break
+		}
+	}
+
+	if open < 0 {
+		return [][]string{words}
+	}
+
+	var stmts [][]string
+	for _, w := range words[open+1:] {
+		if w == "]" {
+			break
+		}
+
+		stmts = append(stmts, append(append([]string{}, words[:open]...), w))
+	}
+
+	return stmts
+}
+
+// pair removes statements that are deleted and added again unchanged, and turns a statement
+// that's deleted and added again with a different value into a change, unless it's in a
+// list of values.
+func (d *ConfigDiff) pair(lists map[*DiffEntry]bool) {
+	key := func(e *DiffEntry) string {
+		return strings.Join(e.Path, "\x00")
+	}
+
+	type group struct {
+		added, deleted []*DiffEntry
+	}
+
+	groups := make(map[string]*group)
+	for _, e := range d.Entries {
+		g, ok := groups[key(e)]
+		if !ok {
+			g = &group{}
+			groups[key(e)] = g
+		}
+
+		if e.Op == DiffAdd {
+			g.added = append(g.added, e)
+		} else {
+			g.deleted = append(g.deleted, e)
+		}
+	}
+
+	drop := make(map[*DiffEntry]bool)
+	for _, g := range groups {
+		for _, del := range g.deleted {
+			for _, add := range g.added {
+				if !drop[add] && add.New == del.Old {
+					drop[add], drop[del] = true, true
+					break
+				}
+			}
+		}
+
+		var added, deleted []*DiffEntry
+		for _, e := range g.added {
+			if !drop[e] {
+				added = append(added, e)
+			}
+		}
+		for _, e := range g.deleted {
+			if !drop[e] {
+				deleted = append(deleted, e)
+			}
+		}
+
+		if len(added) == 1 && len(deleted) == 1 && added[0].New != "" && deleted[0].Old != "" &&
+			!lists[added[0]] && !lists[deleted[0]] {
+			deleted[0].Op = DiffChange
+			deleted[0].New = added[0].New
+			drop[added[0]] = true
+		}
+	}
+
+	entries := d.Entries[:0]
+	for _, e := range d.Entries {
+		if !drop[e] {
+			entries = append(entries, e)
+		}
+	}
+
+	d.Entries = entries
+}
+
+// configNode is an element in an XML configuration.
+type configNode struct {
+	name     string
+	text     string
+	children []*configNode
+}
+
+// DiffXML compares two XML configurations, e.g. from GetConfig("xml"), and returns the
+// changes needed to turn old into new. Either configuration may be the <configuration>
+// element itself, or contain it (e.g. an <rpc-reply>); an empty string is an empty
+// configuration.
+//
+// List entries are matched using their name, and leaves that appear more than once in the
+// same place (e.g. VLAN members) are compared as a set of values. Attributes, such as
+// inactive, and annotations aren't compared.
+func DiffXML(old, new string) (*ConfigDiff, error) {
+	o, err := parseConfigXML(old)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := parseConfigXML(new)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &ConfigDiff{}
+	d.diffNodes(nil, "", o.children, n.children)
+
+	return d, nil
+}
+
+// parseConfigXML parses an XML configuration, and returns the <configuration> element.
+func parseConfigXML(s string) (*configNode, error) {
+	if strings.TrimSpace(s) == "" {
+		return &configNode{name: "configuration"}, nil
+	}
+
+	dec := xml.NewDecoder(strings.NewReader(s))
+
+	var config *configNode
+	var stack []*configNode
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing XML configuration - %s", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			// Annotations (<junos:comment>) aren't statements.
+			if len(stack) > 0 && t.Name.Local == "comment" && strings.Contains(t.Name.Space, "junos") {
+				if err := dec.Skip(); err != nil {
+					return nil, fmt.Errorf("error parsing XML configuration - %s", err)
+				}
+
+				continue
+			}
+
+			n := &configNode{name: t.Name.Local}
+
+			switch {
+			case len(stack) > 0:
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			case config == nil && t.Name.Local == "configuration":
+				config = n
+			}
+
+			if config == nil {
+				continue
+			}
+
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+
+	if config == nil {
+		return nil, errors.New("error parsing XML configuration - no <configuration> element")
+	}
+
+	return config, nil
+}
+
+// key returns the name of a list entry, and whether the node is one.
+func (n *configNode) key() (string, bool) {
+	for _, c := range n.children {
+		if c.name == "name" && len(c.children) == 0 {
+			return strings.TrimSpace(c.text), true
+		}
+	}
+
+	return "", false
+}
+
+// words returns the words the node adds to the path of the statements beneath it, and
+// whether it's a leaf, i.e. the last of those words is its value.
+func (n *configNode) words(parent string) ([]string, bool) {
+	if len(n.children) == 0 {
+		if text := strings.TrimSpace(n.text); text != "" {
+			return []string{n.name, text}, true
+		}
+
+		return []string{n.name}, false
+	}
+
+	key, ok := n.key()
+	if !ok {
+		return []string{n.name}, false
+	}
+
+	if flattenedLists[parent] == n.name {
+		return []string{key}, len(n.children) == 1
+	}
+
+	return []string{n.name, key}, len(n.children) == 1
+}
+
+// diffNodes adds the changes between the children of the same element in two
+// configurations to the diff.
+func (d *ConfigDiff) diffNodes(path []string, parent string, old, new []*configNode) {
+	counts := make(map[string]int)
+	for _, nodes := range [][]*configNode{old, new} {
+		seen := make(map[string]int)
+		for _, n := range nodes {
+			seen[n.name]++
+			if seen[n.name] > counts[n.name] {
+				counts[n.name] = seen[n.name]
+			}
+		}
+	}
+
+	id := func(n *configNode) string {
+		if key, ok := n.key(); ok {
+			return n.name + "\x00" + key
+		}
+
+		if len(n.children) == 0 && counts[n.name] > 1 {
+			return n.name + "\x00" + strings.TrimSpace(n.text)
+		}
+
+		return n.name
+	}
+
+	oldIDs := make(map[string]*configNode)
+	for _, n := range old {
+		oldIDs[id(n)] = n
+	}
+
+	newIDs := make(map[string]*configNode)
+	for _, n := range new {
+		newIDs[id(n)] = n
+	}
+
+	for _, n := range old {
+		if _, ok := newIDs[id(n)]; !ok {
+			d.addNode(DiffDelete, path, parent, n)
+		}
+	}
+
+	for _, n := range new {
+		o, ok := oldIDs[id(n)]
+		if !ok {
+			d.addNode(DiffAdd, path, parent, n)
+			continue
+		}
+
+		if len(n.children) == 0 && len(o.children) == 0 {
+			before, after := strings.TrimSpace(o.text), strings.TrimSpace(n.text)
+			if before != after {
+				d.Entries = append(d.Entries, &DiffEntry{
+					Op:   DiffChange,
+					Path: append(append([]string{}, path...), n.name),
+					Old:  before,
+					New:  after,
+				})
+			}
+
+			continue
+		}
+
+		words, _ := n.words(parent)
+		d.diffNodes(append(append([]string{}, path...), words...), n.name, children(o), children(n))
+	}
+}
+
+// children returns the children of a node, without the name of a list entry.
+func children(n *configNode) []*configNode {
+	var nodes []*configNode
+	for _, c := range n.children {
+		if c.name == "name" && len(c.children) == 0 {
+			continue
+		}
+
+		nodes = append(nodes, c)
+	}
+
+	return nodes
+}
+
+// addNode adds every statement in the node to the diff, as added or deleted.
+func (d *ConfigDiff) addNode(op DiffOp, path []string, parent string, n *configNode) {
+	words, leaf := n.words(parent)
+	path = append(append([]string{}, path...), words...)
+
+	if leaf || len(n.children) == 0 {
+		e := &DiffEntry{Op: op, Path: path}
+		if leaf {
+			e.Path = path[:len(path)-1]
+			if op == DiffAdd {
+				e.New = path[len(path)-1]
+			} else {
+				e.Old = path[len(path)-1]
+			}
+		}
+
+		d.Entries = append(d.Entries, e)
+
+		return
+	}
+
+	for _, c := range children(n) {
+		d.addNode(op, path, n.name, c)
+	}
+}
+
+// Unified renders the diff in the same format as "show | compare". Entries without a path
+// are left out.
+func (d *ConfigDiff) Unified() string {
+	var order []string
+	groups := make(map[string][]string)
+
+	for _, e := range d.Entries {
+		if len(e.Path) == 0 {
+			continue
+		}
+
+		parent := e.Path[:len(e.Path)-1]
+		name := quoteWord(e.Path[len(e.Path)-1])
+
+		header := "[edit]"
+		if len(parent) > 0 {
+			header = "[edit " + quoteWords(parent) + "]"
+		}
+
+		if _, ok := groups[header]; !ok {
+			order = append(order, header)
+		}
+
+		if e.Op != DiffAdd {
+			groups[header] = append(groups[header], "-  "+statement(name, e.Old))
+		}
+		if e.Op != DiffDelete {
+			groups[header] = append(groups[header], "+  "+statement(name, e.New))
+		}
+	}
+
+	var b strings.Builder
+	for _, header := range order {
+		b.WriteString(header + "\n")
+		for _, line := range groups[header] {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// Set renders the diff as the configuration mode commands that make the changes. Changed
+// values are set, replacing the old value. Entries without a path are left out.
+func (d *ConfigDiff) Set() string {
+	var b strings.Builder
+	for _, e := range d.Entries {
+		if len(e.Path) == 0 {
+			continue
+		}
+
+		switch e.Op {
+		case DiffDelete:
+			b.WriteString("delete " + quoteWords(e.Path) + value(e.Old) + "\n")
+		default:
+			b.WriteString("set " + quoteWords(e.Path) + value(e.New) + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// JSON renders the diff as a JSON array of entries, e.g.:
+//
+//	[{"op": "change", "path": ["system", "host-name"], "old": "fw1", "new": "fw2"}]
+func (d *ConfigDiff) JSON() ([]byte, error) {
+	entries := d.Entries
+	if entries == nil {
+		entries = []*DiffEntry{}
+	}
+
+	return json.MarshalIndent(entries, "", "    ")
+}
+
+// statement returns a statement in text format.
+func statement(name, v string) string {
+	return name + value(v) + ";"
+}
+
+// value returns a value to follow a statement's name, quoted if needed.
+func value(v string) string {
+	if v == "" {
+		return ""
+	}
+
+	return " " + quoteWord(v)
+}
+
+// quoteWords joins words into a statement, quoting them as needed.
+func quoteWords(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = quoteWord(w)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// quoteWord quotes a word if it's empty, or contains spaces or special characters.
+func quoteWord(w string) string {
+	if w != "" && !strings.ContainsAny(w, " \t;{}[]\"#\\") {
+		return w
+	}
+
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(w) + "\""
+}
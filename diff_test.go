@@ -0,0 +1,243 @@
+package junos_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scottdware/go-junos"
+)
+
+// compare is the output of "show | compare", with a change, additions, deletions, a list
+// whose values changed, a new hierarchy, and a statement that was only deactivated.
+const compare = `
+[edit system]
+-  host-name fw1;
++  host-name fw2;
+[edit interfaces ge-0/0/0 unit 0 family inet]
++       address 10.0.0.1/24;
+[edit vlans v10]
+-    members [ a b ];
++    members [ a c ];
+[edit]
++  snmp {
++      community public {
++          authorization read-only;
++      }
++      description "my snmp";
++  }
+[edit interfaces]
+   ge-0/0/1 {
++      disable;
+   }
+!  inactive: ge-0/0/2 { ... }
+`
+
+func TestParseDiff(t *testing.T) {
+	d, err := junos.ParseDiff(compare)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `set system host-name fw2
+set interfaces ge-0/0/0 unit 0 family inet address 10.0.0.1/24
+delete vlans v10 members b
+set vlans v10 members c
+set snmp community public authorization read-only
+set snmp description "my snmp"
+set interfaces ge-0/0/1 disable
+`
+	if got := d.Set(); got != want {
+		t.Errorf("got set commands:\n%s\nwant:\n%s", got, want)
+	}
+
+	first := d.Entries[0]
+	if first.Op != junos.DiffChange || first.Old != "fw1" || first.New != "fw2" ||
+		!reflect.DeepEqual(first.Path, []string{"system", "host-name"}) {
+		t.Errorf("got first entry %+v, want the host-name change", first)
+	}
+}
+
+func TestParseDiffUnified(t *testing.T) {
+	d, err := junos.ParseDiff(compare)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `[edit system]
+-  host-name fw1;
++  host-name fw2;
+[edit interfaces ge-0/0/0 unit 0 family inet]
++  address 10.0.0.1/24;
+[edit vlans v10]
+-  members b;
++  members c;
+[edit snmp community public]
++  authorization read-only;
+[edit snmp]
++  description "my snmp";
+[edit interfaces ge-0/0/1]
++  disable;
+`
+	if got := d.Unified(); got != want {
+		t.Errorf("got unified diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := junos.ParseDiff(d.Unified()); err != nil {
+		t.Errorf("ParseDiff of the unified diff returned %v", err)
+	}
+}
+
+func TestParseDiffAnnotations(t *testing.T) {
+	d, err := junos.ParseDiff(`[edit system]
++  /* changed for
++     the migration */
++  host-name fw2;
+[edit]
++  /* one line */
++  snmp {
++      location dc1;
++  }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "set system host-name fw2\nset snmp location dc1\n"
+	if got := d.Set(); got != want {
+		t.Errorf("got set commands:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDiffEmptyPath(t *testing.T) {
+	d := &junos.ConfigDiff{Entries: []*junos.DiffEntry{
+		{Op: junos.DiffAdd},
+		{Op: junos.DiffAdd, Path: []string{"system", "host-name"}, New: "fw2"},
+	}}
+
+	if got, want := d.Unified(), "[edit system]\n+  host-name fw2;\n"; got != want {
+		t.Errorf("got unified diff %q, want %q", got, want)
+	}
+
+	if got, want := d.Set(), "set system host-name fw2\n"; got != want {
+		t.Errorf("got set commands %q, want %q", got, want)
+	}
+}
+
+func TestParseDiffError(t *testing.T) {
+	if _, err := junos.ParseDiff("[edit]\n+ foo {\n"); err == nil {
+		t.Error("ParseDiff didn't return an error for an unterminated block")
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	d, err := junos.ParseDiff(compare)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := d.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries []*junos.DiffEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(entries, d.Entries) {
+		t.Errorf("the JSON doesn't match the entries:\n%s", data)
+	}
+
+	empty, err := (&junos.ConfigDiff{}).JSON()
+	if err != nil || string(empty) != "[]" {
+		t.Errorf("got %s (%v) for an empty diff, want []", empty, err)
+	}
+}
+
+const (
+	oldConfig = `<rpc-reply><configuration>
+<system><host-name>fw1</host-name><services><ssh/></services></system>
+<interfaces><interface><name>ge-0/0/0</name><unit><name>0</name><family><inet><address><name>10.0.0.1/24</name></address></inet></family></unit></interface></interfaces>
+<vlans><vlan><name>v10</name><members>a</members><members>b</members></vlan></vlans>
+</configuration></rpc-reply>`
+
+	newConfig = `<configuration>
+<system><host-name>fw2</host-name><services><ssh/><netconf><ssh/></netconf></services></system>
+<interfaces><interface><name>ge-0/0/0</name><disable/><unit><name>0</name><family><inet><address><name>10.0.0.2/24</name></address></inet></family></unit></interface></interfaces>
+<vlans><vlan><name>v10</name><members>a</members><members>c</members></vlan></vlans>
+<security><zones><security-zone><name>trust</name><interfaces><name>ge-0/0/0.0</name></interfaces></security-zone></zones></security>
+<policy-options><prefix-list><name>mgmt</name><prefix-list-item><name>10.0.0.0/8</name></prefix-list-item></prefix-list></policy-options>
+</configuration>`
+)
+
+func TestDiffXML(t *testing.T) {
+	d, err := junos.DiffXML(oldConfig, newConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	set := d.Set()
+	for _, want := range []string{
+		"set system host-name fw2",
+		"set system services netconf ssh",
+		"set interfaces ge-0/0/0 disable",
+		"delete interfaces ge-0/0/0 unit 0 family inet address 10.0.0.1/24",
+		"set interfaces ge-0/0/0 unit 0 family inet address 10.0.0.2/24",
+		"delete vlans v10 members b",
+		"set vlans v10 members c",
+		"set security zones security-zone trust interfaces ge-0/0/0.0",
+		"set policy-options prefix-list mgmt 10.0.0.0/8",
+	} {
+		if !strings.Contains(set, want+"\n") {
+			t.Errorf("the set commands don't include %q:\n%s", want, set)
+		}
+	}
+
+	if strings.Contains(set, "services ssh") || strings.Contains(set, "members a") {
+		t.Errorf("the set commands include statements that didn't change:\n%s", set)
+	}
+}
+
+func TestDiffXMLEmpty(t *testing.T) {
+	d, err := junos.DiffXML("", oldConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range d.Entries {
+		if e.Op != junos.DiffAdd {
+			t.Errorf("got %+v, want only additions", e)
+		}
+	}
+
+	if len(d.Entries) != 5 {
+		t.Errorf("got %d entries, want 5:\n%s", len(d.Entries), d.Set())
+	}
+
+	same, err := junos.DiffXML(oldConfig, oldConfig)
+	if err != nil || len(same.Entries) != 0 {
+		t.Errorf("got %v (%v) for the same configuration, want no entries", same.Entries, err)
+	}
+}
+
+func TestDiffXMLError(t *testing.T) {
+	if _, err := junos.DiffXML("<foo/>", oldConfig); err == nil {
+		t.Error("DiffXML didn't return an error for XML without a configuration")
+	}
+}
+
+func TestStructuredDiff(t *testing.T) {
+	j, _ := newSession(t)
+
+	d, err := j.StructuredDiff(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(d.Entries) == 0 {
+		t.Error("StructuredDiff returned no entries")
+	}
+}
@@ -0,0 +1,203 @@
+package junos
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Juniper/go-netconf/netconf"
+)
+
+// WarningPolicy controls whether the warnings reported by the device, e.g. "statement has no
+// contents; ignored", fail an RPC.
+type WarningPolicy int
+
+const (
+	// IgnoreWarnings doesn't fail an RPC that only has warnings. The warnings are returned
+	// by the methods that return results, e.g. CommitWithResult, and dropped otherwise.
+	IgnoreWarnings WarningPolicy = iota
+
+	// FatalWarnings fails an RPC that has warnings, the same as if they were errors.
+	FatalWarnings
+)
+
+// RPCError is returned when the device reports an error for an RPC, e.g. a commit that fails,
+// or a lock that's denied. Its fields describe the first error the device reported, and
+// Errors holds every error and warning in the reply, in the order they were reported. Use
+// errors.As to get at it, and the error tag to tell errors apart:
+//
+//	var rpcErr *junos.RPCError
+//	if errors.As(err, &rpcErr) && rpcErr.Tag == "lock-denied" {
+//		// Someone else has the configuration locked.
+//	}
+type RPCError struct {
+	Type       string      `xml:"error-type"`
+	Tag        string      `xml:"error-tag"`
+	Severity   string      `xml:"error-severity"`
+	Path       string      `xml:"error-path"`
+	BadElement string      `xml:"error-info>bad-element"`
+	Message    string      `xml:"error-message"`
+	Errors     []*RPCError `xml:"-"`
+}
+
+// Error returns the message of each error reported (but not the warnings), along with the
+// path and element they apply to.
+func (e *RPCError) Error() string {
+	var msgs []string
+	for _, m := range e.Errors {
+		if m.Severity != "warning" {
+			msgs = append(msgs, m.String())
+		}
+	}
+
+	if len(msgs) == 0 {
+		return e.String()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// String returns the message, along with the path and element it applies to.
+func (e *RPCError) String() string {
+	msg := strings.TrimSpace(e.Message)
+	where := strings.TrimSpace(strings.TrimSpace(e.Path) + " " + strings.TrimSpace(e.BadElement))
+
+	if where == "" {
+		return msg
+	}
+
+	return fmt.Sprintf("%s: %s", where, msg)
+}
+
+// newRPCError returns an *RPCError for the errors reported by the device, or nil if there
+// are none.
+func newRPCError(errs []*RPCError) *RPCError {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	first := errs[0]
+	for _, m := range errs {
+		if m.Severity != "warning" {
+			first = m
+			break
+		}
+	}
+
+	e := *first
+	e.Errors = errs
+
+	return &e
+}
+
+// replyErrors returns the errors (and warnings) in an RPC reply.
+func replyErrors(reply *netconf.RPCReply) []*RPCError {
+	if reply == nil {
+		return nil
+	}
+
+	errs := make([]*RPCError, 0, len(reply.Errors))
+	for _, m := range reply.Errors {
+		errs = append(errs, convertRPCError(m))
+	}
+
+	return errs
+}
+
+// replyError returns an *RPCError for the errors in an RPC reply, or nil if there are none.
+// Warnings are dropped, unless they're fatal.
+func (j *Junos) replyError(reply *netconf.RPCReply) error {
+	_, err := j.checkErrors(replyErrors(reply))
+
+	return err
+}
+
+// checkErrors returns the warnings among the errors reported by the device, and an
+// *RPCError holding all of them if any are errors (or warnings, when they're fatal).
+func (j *Junos) checkErrors(errs ...[]*RPCError) ([]*RPCError, error) {
+	var all, warnings []*RPCError
+	fatal := false
+
+	for _, e := range errs {
+		all = append(all, e...)
+	}
+
+	for _, e := range all {
+		if e.Severity == "warning" {
+			warnings = append(warnings, e)
+			fatal = fatal || j.WarningPolicy == FatalWarnings
+		} else {
+			fatal = true
+		}
+	}
+
+	if fatal {
+		return warnings, newRPCError(all)
+	}
+
+	return warnings, nil
+}
+
+// stripErrors removes the top-level <rpc-error> elements from the reply's data, so the data
+// can be parsed when the device reports warnings alongside it. The errors are still in
+// reply.Errors.
+func stripErrors(reply *netconf.RPCReply) {
+	if reply == nil || len(reply.Errors) == 0 {
+		return
+	}
+
+	data := []byte(reply.Data)
+	d := xml.NewDecoder(bytes.NewReader(data))
+
+	var buf bytes.Buffer
+	last := int64(0)
+	for {
+		start := d.InputOffset()
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return
+		}
+
+		se, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if se.Name.Local == "rpc-error" {
+			buf.Write(data[last:start])
+		}
+
+		if err := d.Skip(); err != nil {
+			return
+		}
+
+		if se.Name.Local == "rpc-error" {
+			last = d.InputOffset()
+		}
+	}
+
+	buf.Write(data[last:])
+	reply.Data = buf.String()
+}
+
+// convertRPCError converts an <rpc-error> parsed by go-netconf, which doesn't keep the
+// bad-element.
+func convertRPCError(m netconf.RPCError) *RPCError {
+	e := &RPCError{}
+	if err := xml.Unmarshal([]byte("<rpc-error>"+m.Info+"</rpc-error>"), e); err != nil {
+		e = &RPCError{
+			Type:     m.Type,
+			Tag:      m.Tag,
+			Severity: m.Severity,
+			Path:     m.Path,
+			Message:  m.Message,
+		}
+	}
+
+	return e
+}
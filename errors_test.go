@@ -0,0 +1,105 @@
+package junos_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+func TestRPCErrorLockDenied(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Handle("lock-configuration", junostest.LockError())
+
+	var rpcErr *junos.RPCError
+	if err := j.Lock(); !errors.As(err, &rpcErr) || rpcErr.Tag != "lock-denied" {
+		t.Fatalf("Lock returned %#v", err)
+	}
+}
+
+func TestRPCErrorCommit(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Handle("commit-configuration", junostest.CommitError("[edit security policies]", "policy web", "missing mandatory statement: 'match'"))
+
+	commits := map[string]func() error{
+		"Commit":      j.Commit,
+		"CommitCheck": j.CommitCheck,
+		"CommitConfirm": func() error {
+			return j.CommitConfirm(5)
+		},
+	}
+
+	for name, commit := range commits {
+		var rpcErr *junos.RPCError
+		if err := commit(); !errors.As(err, &rpcErr) {
+			t.Errorf("%s returned %#v", name, err)
+			continue
+		}
+
+		if rpcErr.Path != "[edit security policies]" || rpcErr.BadElement != "policy web" || len(rpcErr.Errors) != 2 {
+			t.Errorf("%s returned %+v", name, rpcErr)
+		}
+
+		want := "[edit security policies] policy web: missing mandatory statement: 'match'; configuration check-out failed"
+		if rpcErr.Error() != want {
+			t.Errorf("%s returned %q, want %q", name, rpcErr.Error(), want)
+		}
+	}
+}
+
+func TestRPCErrorLoad(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Handle("load-configuration", `<load-configuration-results>
+<rpc-error>
+<error-severity>error</error-severity>
+<error-message>syntax error</error-message>
+<error-info><bad-element>foo</bad-element></error-info>
+</rpc-error>
+<load-error-count>1</load-error-count>
+</load-configuration-results>`)
+
+	var rpcErr *junos.RPCError
+	if err := j.Config([]string{"set foo"}, "set", true); !errors.As(err, &rpcErr) || rpcErr.BadElement != "foo" {
+		t.Fatalf("Config returned %#v", err)
+	}
+
+	if n := srv.Received("commit-configuration"); n != 0 {
+		t.Error("the configuration was committed after it failed to load")
+	}
+}
+
+func TestRPCErrorWarnings(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Handle("get-arp-table-information",
+		junostest.RPCError("protocol", "operation-failed", "error", "permission denied")+
+			junostest.RPCError("protocol", "operation-failed", "warning", "statement has no contents"))
+
+	_, err := j.View("arp")
+
+	var rpcErr *junos.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Message != "permission denied" || len(rpcErr.Errors) != 2 {
+		t.Fatalf("View returned %#v", err)
+	}
+
+	if strings.Contains(err.Error(), "no contents") {
+		t.Errorf("the error %q includes the warning", err)
+	}
+}
+
+func TestRPCErrorDiff(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Handle("get-configuration", `<configuration-information>
+<rpc-error>
+<error-severity>error</error-severity>
+<error-message>
+rollback 9 not found
+</error-message>
+</rpc-error>
+</configuration-information>`)
+
+	if _, err := j.Diff(9); err == nil || err.Error() != "rollback 9 not found" {
+		t.Errorf("Diff returned %v", err)
+	}
+}
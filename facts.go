@@ -0,0 +1,343 @@
+package junos
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// Facts holds information about the device, gathered by GatherFacts when the session is
+// established.
+//
+// Hostname, Model, Personality, Version and Packages come from the software information,
+// and are always gathered. The rest each take another RPC to gather, and are skipped when
+// MinimalFacts is set. VirtualChassis is only set on devices that are part of a virtual
+// chassis, and Cluster on SRXs in a chassis cluster.
+type Facts struct {
+	Hostname       string
+	Domain         string
+	Model          string
+	Personality    string
+	Version        string
+	SerialNumber   string
+	Uptime         time.Duration
+	RoutingEngines []RoutingEngineFacts
+	Packages       []Package
+	VirtualChassis []VirtualChassisMember
+	Cluster        *ChassisCluster
+}
+
+// RoutingEngineFacts holds the state of each routing engine. Name is the routing engine's
+// slot (e.g. "0"), or the node it's on in a chassis cluster (e.g. "node0").
+type RoutingEngineFacts struct {
+	Name            string
+	Model           string
+	MastershipState string
+	Status          string
+	Uptime          time.Duration
+}
+
+// Package is a software package installed on the device.
+type Package struct {
+	Name    string `xml:"name"`
+	Comment string `xml:"comment"`
+}
+
+// VirtualChassisMember is a member of a virtual chassis.
+type VirtualChassisMember struct {
+	ID           string `xml:"member-id"`
+	SerialNumber string `xml:"member-serial-number"`
+	Model        string `xml:"member-model"`
+	Role         string `xml:"member-role"`
+	Status       string `xml:"member-status"`
+}
+
+// ChassisCluster holds the chassis cluster state of an SRX. Nodes holds the state of each
+// node in redundancy group 0, i.e. which node is the primary routing engine.
+type ChassisCluster struct {
+	ID    string
+	Nodes []ChassisClusterNode
+}
+
+// ChassisClusterNode is the state of a node in a chassis cluster.
+type ChassisClusterNode struct {
+	Name     string
+	Priority string
+	Status   string
+}
+
+// personalities maps model prefixes to the device's personality. Longer prefixes come first.
+var personalities = []struct {
+	prefix      string
+	personality string
+}{
+	{"VSRX", "SRX"},
+	{"SRX", "SRX"},
+	{"VMX", "MX"},
+	{"MX", "MX"},
+	{"EX", "EX"},
+	{"QFX", "QFX"},
+	{"PTX", "PTX"},
+	{"ACX", "ACX"},
+	{"NFX", "NFX"},
+	{"JRR", "MX"},
+	{"M", "M"},
+	{"T", "T"},
+}
+
+type factsSoftware struct {
+	XMLName  xml.Name  `xml:"software-information"`
+	Hostname string    `xml:"host-name"`
+	Model    string    `xml:"product-model"`
+	Version  string    `xml:"junos-version"`
+	Packages []Package `xml:"package-information"`
+}
+
+type factsChassis struct {
+	XMLName xml.Name `xml:"chassis-inventory"`
+	Serial  string   `xml:"chassis>serial-number"`
+}
+
+type factsRE struct {
+	XMLName xml.Name `xml:"route-engine-information"`
+	RE      []struct {
+		Slot            string `xml:"slot"`
+		Model           string `xml:"model"`
+		MastershipState string `xml:"mastership-state"`
+		Status          string `xml:"status"`
+		Uptime          struct {
+			Seconds int64 `xml:"seconds,attr"`
+		} `xml:"up-time"`
+	} `xml:"route-engine"`
+}
+
+type factsMultiRE struct {
+	XMLName xml.Name           `xml:"multi-routing-engine-results"`
+	Items   []factsMultiREItem `xml:"multi-routing-engine-item"`
+}
+
+type factsMultiREItem struct {
+	Name string  `xml:"re-name"`
+	RE   factsRE `xml:"route-engine-information"`
+}
+
+type factsDomain struct {
+	Domain string `xml:"system>domain-name"`
+}
+
+type factsVirtualChassis struct {
+	XMLName xml.Name               `xml:"virtual-chassis-information"`
+	Members []VirtualChassisMember `xml:"member-list>member"`
+}
+
+type factsCluster struct {
+	XMLName xml.Name `xml:"chassis-cluster-status"`
+	ID      string   `xml:"cluster-id"`
+	Groups  []struct {
+		ID       string   `xml:"redundancy-group-id"`
+		Names    []string `xml:"device-stats>device-name"`
+		Priority []string `xml:"device-stats>device-priority"`
+		Status   []string `xml:"device-stats>redundancy-group-status"`
+	} `xml:"redundancy-group"`
+}
+
+// SessionOption changes how a session is established by NewSessionWithConfig,
+// NewSessionFromNetConn, NewSessionFromNetconf, NewSessionTLS and NewSessionFromRecording.
+// (For NewSession, the same options are set in the AuthMethod.)
+type SessionOption int
+
+const (
+	// GatherMinimalFacts only gathers the facts that come with the software information (see
+	// Facts), rather than sending an RPC for each of the rest, which speeds up connecting
+	// to large numbers of devices. It's the same as setting MinimalFacts in the AuthMethod
+	// for NewSession; either way, it sets MinimalFacts on the session, which is what
+	// GatherFacts (and re-establishing the session) goes by.
+	GatherMinimalFacts SessionOption = iota + 1
+)
+
+// sessionConfig holds the options a session is established with. The rate limits and retry
+// policy are set before the facts are gathered, so they apply to those RPCs too.
+type sessionConfig struct {
+	minimalFacts bool
+	fleetLimit   *RateLimiter
+	rateLimit    *RateLimiter
+	retryPolicy  *RetryPolicy
+}
+
+// newSessionConfig returns the config for the given options.
+func newSessionConfig(options []SessionOption) sessionConfig {
+	var cfg sessionConfig
+	for _, o := range options {
+		if o == GatherMinimalFacts {
+			cfg.minimalFacts = true
+		}
+	}
+
+	return cfg
+}
+
+// gatherFacts fills in j.Facts, from the software information in data, and (unless
+// MinimalFacts is set) the RPCs for the rest of the facts.
+func (j *Junos) gatherFacts(ctx context.Context, data string) error {
+	payloads, ok := multiREPayloads(data)
+	if !ok {
+		payloads = []string{data}
+	}
+
+	facts := &Facts{}
+	for _, p := range payloads {
+		var sw factsSoftware
+		if err := xml.Unmarshal([]byte(p), &sw); err != nil {
+			continue
+		}
+
+		if facts.Hostname == "" {
+			facts.Hostname = sw.Hostname
+			facts.Model = strings.ToUpper(sw.Model)
+			facts.Version = sw.Version
+			facts.Packages = sw.Packages
+
+			if facts.Version == "" && len(sw.Packages) > 0 {
+				facts.Version = findVersion(sw.Packages[0].Comment)
+			}
+		}
+	}
+
+	for _, p := range personalities {
+		if strings.HasPrefix(facts.Model, p.prefix) {
+			facts.Personality = p.personality
+			break
+		}
+	}
+
+	if j.MinimalFacts {
+		j.Facts = facts
+		return nil
+	}
+
+	data, err := j.optionalFact(ctx, rpcFactsChassis)
+	if err != nil {
+		return err
+	}
+	if payloads, ok := multiREPayloads(data); ok && len(payloads) > 0 {
+		data = payloads[0]
+	}
+
+	var chassis factsChassis
+	if xml.Unmarshal([]byte(data), &chassis) == nil {
+		facts.SerialNumber = strings.TrimSpace(chassis.Serial)
+	}
+
+	if err := j.gatherRoutingEngineFacts(ctx, facts); err != nil {
+		return err
+	}
+
+	if data, err = j.optionalFact(ctx, rpcFactsDomain); err != nil {
+		return err
+	}
+
+	var domain factsDomain
+	if xml.Unmarshal([]byte(data), &domain) == nil {
+		facts.Domain = strings.TrimSpace(domain.Domain)
+	}
+
+	switch facts.Personality {
+	case "EX", "QFX":
+		if data, err = j.optionalFact(ctx, rpcFactsVirtualChassis); err != nil {
+			return err
+		}
+
+		var vc factsVirtualChassis
+		if xml.Unmarshal([]byte(data), &vc) == nil {
+			facts.VirtualChassis = vc.Members
+		}
+	case "SRX":
+		if data, err = j.optionalFact(ctx, rpcFactsCluster); err != nil {
+			return err
+		}
+
+		var cluster factsCluster
+		if xml.Unmarshal([]byte(data), &cluster) == nil && cluster.ID != "" {
+			facts.Cluster = &ChassisCluster{ID: strings.TrimSpace(cluster.ID)}
+			for _, g := range cluster.Groups {
+				if strings.TrimSpace(g.ID) != "0" {
+					continue
+				}
+
+				for i, name := range g.Names {
+					node := ChassisClusterNode{Name: strings.TrimSpace(name)}
+					if i < len(g.Priority) {
+						node.Priority = strings.TrimSpace(g.Priority[i])
+					}
+					if i < len(g.Status) {
+						node.Status = strings.TrimSpace(g.Status[i])
+					}
+
+					facts.Cluster.Nodes = append(facts.Cluster.Nodes, node)
+				}
+			}
+		}
+	}
+
+	j.Facts = facts
+
+	return nil
+}
+
+// gatherRoutingEngineFacts fills in the state of each routing engine, and the device's
+// uptime (that of the master routing engine).
+func (j *Junos) gatherRoutingEngineFacts(ctx context.Context, facts *Facts) error {
+	data, err := j.optionalFact(ctx, rpcFactsRE)
+	if err != nil {
+		return err
+	}
+
+	var multi factsMultiRE
+	if err := xml.Unmarshal([]byte(data), &multi); err != nil {
+		var re factsRE
+		if err := xml.Unmarshal([]byte(data), &re); err != nil {
+			return nil
+		}
+
+		multi.Items = append(multi.Items, factsMultiREItem{RE: re})
+	}
+
+	for _, item := range multi.Items {
+		for _, re := range item.RE.RE {
+			name := strings.TrimSpace(item.Name)
+			if name == "" {
+				name = strings.TrimSpace(re.Slot)
+			}
+
+			ref := RoutingEngineFacts{
+				Name:            name,
+				Model:           strings.TrimSpace(re.Model),
+				MastershipState: strings.TrimSpace(re.MastershipState),
+				Status:          strings.TrimSpace(re.Status),
+				Uptime:          time.Duration(re.Uptime.Seconds) * time.Second,
+			}
+			facts.RoutingEngines = append(facts.RoutingEngines, ref)
+
+			// Junos 20.x and later call the master routing engine the primary.
+			if facts.Uptime == 0 && (ref.MastershipState == "" || ref.MastershipState == "master" || ref.MastershipState == "primary") {
+				facts.Uptime = ref.Uptime
+			}
+		}
+	}
+
+	return nil
+}
+
+// optionalFact runs the RPC for a fact, and returns the reply. Errors reported by the device
+// are ignored, since they mean the fact doesn't apply to it (e.g. virtual chassis on a
+// standalone switch), and an empty reply is returned instead.
+func (j *Junos) optionalFact(ctx context.Context, rpc string) (string, error) {
+	var data string
+	err := j.RPCContext(ctx, rpc, &data)
+	if _, ok := err.(*RPCError); ok {
+		return "", nil
+	}
+
+	return data, err
+}
@@ -0,0 +1,170 @@
+package junos_test
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+	"golang.org/x/crypto/ssh"
+)
+
+const clusterRouteEngines = `<multi-routing-engine-results>
+<multi-routing-engine-item>
+<re-name>node0</re-name>
+<route-engine-information>
+<route-engine><slot>0</slot><mastership-state>master</mastership-state><up-time seconds="60">1 min</up-time></route-engine>
+</route-engine-information>
+</multi-routing-engine-item>
+<multi-routing-engine-item>
+<re-name>node1</re-name>
+<route-engine-information>
+<route-engine><slot>0</slot><mastership-state>backup</mastership-state></route-engine>
+</route-engine-information>
+</multi-routing-engine-item>
+</multi-routing-engine-results>`
+
+func TestFacts(t *testing.T) {
+	j, _ := newSession(t)
+
+	f := j.Facts
+	if f.Hostname != "fw1" || f.Domain != "example.net" || f.Model != "SRX300" || f.Personality != "SRX" || f.Version != "18.4R2-S3" {
+		t.Errorf("got facts %+v", f)
+	}
+
+	if f.SerialNumber != "CV0118AF0123" || f.Uptime != 1221729*time.Second || len(f.RoutingEngines) != 1 || len(f.Packages) != 1 {
+		t.Errorf("got facts %+v", f)
+	}
+
+	if f.Cluster != nil {
+		t.Errorf("got chassis cluster %+v on a standalone device", f.Cluster)
+	}
+}
+
+func TestFactsChassisCluster(t *testing.T) {
+	srv := newServer(t)
+	srv.Handle("get-software-information", junostest.SoftwareInformationMultiRE)
+	srv.Handle("get-chassis-cluster-status", junostest.ChassisClusterStatus)
+	srv.Handle("get-route-engine-information", clusterRouteEngines)
+
+	j, err := junos.NewSession(srv.Addr, srv.Auth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	f := j.Facts
+	if f.Hostname != "fw1-node0" || f.Uptime != time.Minute {
+		t.Errorf("got facts %+v", f)
+	}
+
+	if len(f.RoutingEngines) != 2 || f.RoutingEngines[1].Name != "node1" || f.RoutingEngines[1].MastershipState != "backup" {
+		t.Errorf("got routing engines %+v", f.RoutingEngines)
+	}
+
+	if f.Cluster == nil || len(f.Cluster.Nodes) != 2 || f.Cluster.Nodes[0].Status != "primary" {
+		t.Errorf("got chassis cluster %+v", f.Cluster)
+	}
+}
+
+func TestFactsPrimaryUptime(t *testing.T) {
+	srv := newServer(t)
+	srv.Handle("get-route-engine-information", `<route-engine-information>
+<route-engine><slot>0</slot><mastership-state>backup</mastership-state><up-time junos:seconds="120">2 mins</up-time></route-engine>
+<route-engine><slot>1</slot><mastership-state>primary</mastership-state><up-time junos:seconds="60">1 min</up-time></route-engine>
+</route-engine-information>`)
+
+	j, err := junos.NewSession(srv.Addr, srv.Auth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	if j.Facts.Uptime != time.Minute {
+		t.Errorf("got uptime %s, want the primary routing engine's 1m0s", j.Facts.Uptime)
+	}
+}
+
+func TestMinimalFacts(t *testing.T) {
+	srv := newServer(t)
+
+	auth := srv.Auth()
+	auth.MinimalFacts = true
+
+	j, err := junos.NewSession(srv.Addr, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	if !j.MinimalFacts || j.Facts.Model != "SRX300" || j.Facts.SerialNumber != "" {
+		t.Errorf("got facts %+v", j.Facts)
+	}
+
+	if reqs := srv.Requests(); len(reqs) != 1 || reqs[0].Name != "get-software-information" {
+		t.Errorf("got requests %+v, want just get-software-information", reqs)
+	}
+}
+
+func TestMinimalFactsOption(t *testing.T) {
+	srv := newServer(t)
+
+	config := &ssh.ClientConfig{
+		User:            srv.Username,
+		Auth:            []ssh.AuthMethod{ssh.Password(srv.Password)},
+		HostKeyCallback: ssh.FixedHostKey(srv.HostKey()),
+	}
+
+	j, err := junos.NewSessionWithConfig(srv.Addr, config, junos.GatherMinimalFacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	if !j.MinimalFacts || srv.Received("get-chassis-inventory") != 0 {
+		t.Errorf("NewSessionWithConfig gathered every fact with GatherMinimalFacts: %+v", srv.Requests())
+	}
+
+	nc, err := net.Dial("tcp", srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j2, err := junos.NewSessionFromNetConn(srv.Addr, nc, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j2.Close()
+
+	if j2.MinimalFacts || srv.Received("get-chassis-inventory") != 1 {
+		t.Error("NewSessionFromNetConn didn't gather every fact by default")
+	}
+}
+
+func TestMinimalFactsReconnect(t *testing.T) {
+	j, srv := newSession(t)
+
+	err := j.EnableReconnect(&junos.ReconnectOptions{MinBackoff: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	j.MinimalFacts = true
+	srv.Reset()
+	srv.CloseClientConnections()
+
+	// The first RPC after the connection drops may fail, but the next one mustn't.
+	j.Ping()
+	if err := j.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := srv.Received("get-chassis-inventory"); n != 0 {
+		t.Error("every fact was gathered again on reconnect, even though MinimalFacts is set")
+	}
+
+	if n := srv.Received("get-software-information"); n != 1 {
+		t.Errorf("the facts were gathered %d times on reconnect, want 1", n)
+	}
+}
@@ -0,0 +1,205 @@
+package junos
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultFleetWorkers is the number of devices a Fleet works on at once, if Workers isn't set.
+const defaultFleetWorkers = 10
+
+// Fleet runs operations concurrently across many Junos devices. Sessions are opened using
+// Auth, with at most Workers devices being worked on at once. If set, Progress is called
+// (one at a time) as each device finishes.
+//
+// RateLimit limits the RPCs sent across the whole fleet, including connecting to each device
+// and gathering its facts. SessionRateLimit, if set, is called for each session to give it a
+// rate limit of its own, e.g. so no single device is sent more than a few RPCs a second.
+// RetryPolicy is given to each session, to retry operations that fail with transient errors.
+// Both are in place before the facts are gathered.
+type Fleet struct {
+	Hosts            []string
+	Auth             *AuthMethod
+	Workers          int
+	Progress         func(result *FleetResult, done, total int)
+	RateLimit        *RateLimiter
+	SessionRateLimit func() *RateLimiter
+	RetryPolicy      *RetryPolicy
+}
+
+// FleetFunc is run against each device in a Fleet. Whatever it returns is stored in the
+// device's FleetResult.
+type FleetFunc func(ctx context.Context, j *Junos) (interface{}, error)
+
+// FleetResult holds the outcome of running an operation on a single device. Err is set if
+// either connecting to the device, or the operation itself, failed.
+type FleetResult struct {
+	Host     string
+	Value    interface{}
+	Err      error
+	Duration time.Duration
+}
+
+// FleetSummary contains the totals for a Fleet run.
+type FleetSummary struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	Elapsed   time.Duration
+}
+
+// FleetResults contains the result for every device, in the same order as Fleet.Hosts, and
+// a summary of the run.
+type FleetResults struct {
+	Results []FleetResult
+	Summary FleetSummary
+}
+
+// NewFleet creates a fleet of the given hosts, which are all authenticated to using auth.
+// Workers is the number of devices to work on at once.
+func NewFleet(hosts []string, auth *AuthMethod, workers int) *Fleet {
+	return &Fleet{
+		Hosts:   hosts,
+		Auth:    auth,
+		Workers: workers,
+	}
+}
+
+// Run connects to every device in the fleet, runs fn against it and closes the session. If
+// ctx is done before every device has been worked on, the remaining devices fail with the
+// context's error.
+func (f *Fleet) Run(ctx context.Context, fn FleetFunc) *FleetResults {
+	start := time.Now()
+	total := len(f.Hosts)
+	results := &FleetResults{
+		Results: make([]FleetResult, total),
+	}
+
+	workers := f.Workers
+	if workers <= 0 {
+		workers = defaultFleetWorkers
+	}
+	if workers > total {
+		workers = total
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	done := 0
+	jobs := make(chan int)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				r := &results.Results[i]
+				f.runOne(ctx, r, f.Hosts[i], fn)
+
+				mu.Lock()
+				done++
+				if f.Progress != nil {
+					f.Progress(r, done, total)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range f.Hosts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	results.Summary.Total = total
+	for _, r := range results.Results {
+		if r.Err != nil {
+			results.Summary.Failed++
+		} else {
+			results.Summary.Succeeded++
+		}
+	}
+	results.Summary.Elapsed = time.Since(start)
+
+	return results
+}
+
+// runOne runs fn against a single device, storing the outcome in r.
+func (f *Fleet) runOne(ctx context.Context, r *FleetResult, host string, fn FleetFunc) {
+	start := time.Now()
+	r.Host = host
+
+	defer func() {
+		r.Duration = time.Since(start)
+	}()
+
+	if err := ctx.Err(); err != nil {
+		r.Err = err
+		return
+	}
+
+	if err := f.RateLimit.Wait(ctx); err != nil {
+		r.Err = err
+		return
+	}
+
+	cfg := sessionConfig{
+		minimalFacts: f.Auth.MinimalFacts,
+		fleetLimit:   f.RateLimit,
+		retryPolicy:  f.RetryPolicy,
+	}
+	if f.SessionRateLimit != nil {
+		cfg.rateLimit = f.SessionRateLimit()
+	}
+
+	j, err := newSession(ctx, host, f.Auth, cfg)
+	if err != nil {
+		if j != nil {
+			j.Close()
+		}
+		r.Err = err
+		return
+	}
+	defer j.Close()
+
+	r.Value, r.Err = fn(ctx, j)
+}
+
+// Command runs the operational mode command on every device in the fleet. See Junos.Command
+// for the format options. Each result's Value is the output, as a string.
+func (f *Fleet) Command(ctx context.Context, cmd string, format ...string) *FleetResults {
+	return f.Run(ctx, func(ctx context.Context, j *Junos) (interface{}, error) {
+		return j.CommandContext(ctx, cmd, format...)
+	})
+}
+
+// GetConfig returns the configuration from every device in the fleet. See Junos.GetConfig
+// for the format and section options. Each result's Value is the configuration, as a string.
+func (f *Fleet) GetConfig(ctx context.Context, format string, section ...string) *FleetResults {
+	return f.Run(ctx, func(ctx context.Context, j *Junos) (interface{}, error) {
+		return j.GetConfigContext(ctx, format, section...)
+	})
+}
+
+// View gathers the given view from every device in the fleet. See Junos.View for the
+// supported views. Each result's Value is a *Views.
+func (f *Fleet) View(ctx context.Context, view string, option ...string) *FleetResults {
+	return f.Run(ctx, func(ctx context.Context, j *Junos) (interface{}, error) {
+		return j.ViewContext(ctx, view, option...)
+	})
+}
+
+// Errors returns the error for each device that failed, keyed by host.
+func (r *FleetResults) Errors() map[string]error {
+	errs := make(map[string]error)
+	for _, res := range r.Results {
+		if res.Err != nil {
+			errs[res.Host] = res.Err
+		}
+	}
+
+	return errs
+}
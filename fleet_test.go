@@ -0,0 +1,137 @@
+package junos_test
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+// closedAddr returns an address nothing is listening on.
+func closedAddr(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	return addr
+}
+
+func TestFleetCommand(t *testing.T) {
+	var hosts []string
+	for i := 0; i < 3; i++ {
+		srv := newServer(t)
+		srv.HandleCommand("show chassis alarms", "No alarms currently active")
+		hosts = append(hosts, srv.Addr)
+	}
+	down := closedAddr(t)
+	hosts = append(hosts, down)
+
+	var mu sync.Mutex
+	progress := 0
+
+	auth := &junos.AuthMethod{Credentials: []string{"admin", "Juniper123!"}}
+	f := junos.NewFleet(hosts, auth, 2)
+	f.Progress = func(r *junos.FleetResult, done, total int) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		progress++
+		if done != progress || total != len(hosts) {
+			t.Errorf("Progress called with %d of %d, after %d results", done, total, progress)
+		}
+	}
+
+	res := f.Command(context.Background(), "show chassis alarms", "text")
+
+	if progress != len(hosts) {
+		t.Errorf("Progress was called %d times, want %d", progress, len(hosts))
+	}
+
+	if res.Summary.Total != 4 || res.Summary.Succeeded != 3 || res.Summary.Failed != 1 {
+		t.Errorf("Summary is %+v", res.Summary)
+	}
+
+	for i, r := range res.Results {
+		if r.Host != hosts[i] {
+			t.Errorf("result %d is for %s, want %s", i, r.Host, hosts[i])
+		}
+
+		if r.Host == down {
+			continue
+		}
+
+		if out, _ := r.Value.(string); r.Err != nil || !strings.Contains(out, "No alarms") {
+			t.Errorf("result for %s is %q, %v", r.Host, out, r.Err)
+		}
+	}
+
+	if errs := res.Errors(); len(errs) != 1 || errs[down] == nil {
+		t.Errorf("Errors returned %v", errs)
+	}
+}
+
+func TestFleetWorkers(t *testing.T) {
+	var mu sync.Mutex
+	running, most := 0, 0
+
+	var hosts []string
+	for i := 0; i < 6; i++ {
+		srv := newServer(t)
+		srv.HandleFunc("command", func(*junostest.Request) string {
+			mu.Lock()
+			running++
+			if running > most {
+				most = running
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			return "<output>ok</output>"
+		})
+		hosts = append(hosts, srv.Addr)
+	}
+
+	auth := &junos.AuthMethod{Credentials: []string{"admin", "Juniper123!"}}
+	res := junos.NewFleet(hosts, auth, 2).Command(context.Background(), "show version")
+
+	if res.Summary.Succeeded != len(hosts) {
+		t.Fatal(res.Errors())
+	}
+
+	if most > 2 {
+		t.Errorf("%d devices were worked on at once, want at most 2", most)
+	}
+}
+
+func TestFleetCancelled(t *testing.T) {
+	srv := newServer(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	auth := &junos.AuthMethod{Credentials: []string{"admin", "Juniper123!"}}
+	res := junos.NewFleet([]string{srv.Addr, srv.Addr}, auth, 1).Command(ctx, "show version")
+
+	if res.Summary.Failed != 2 || res.Results[0].Err != context.Canceled {
+		t.Errorf("Summary is %+v, first error %v", res.Summary, res.Results[0].Err)
+	}
+
+	if srv.Sessions() != 0 {
+		t.Errorf("%d sessions were established with a cancelled context", srv.Sessions())
+	}
+}
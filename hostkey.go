@@ -0,0 +1,159 @@
+package junos
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostKeyError is returned when the host key presented by a device can't be verified. If
+// Want is empty, the device isn't listed in the known_hosts file at all; otherwise the
+// key it presented doesn't match any of the keys we expected.
+type HostKeyError struct {
+	Host        string
+	Fingerprint string
+	Want        []string
+	Revoked     bool
+}
+
+func (e *HostKeyError) Error() string {
+	switch {
+	case e.Revoked:
+		return fmt.Sprintf("host key %s for %s has been revoked", e.Fingerprint, e.Host)
+	case len(e.Want) == 0:
+		return fmt.Sprintf("host key %s for %s is unknown", e.Fingerprint, e.Host)
+	}
+
+	return fmt.Sprintf("host key mismatch for %s - got %s, want %s", e.Host, e.Fingerprint, strings.Join(e.Want, " or "))
+}
+
+// tofuMu serializes writes to known_hosts files when trusting hosts on first use.
+var tofuMu sync.Mutex
+
+// hostKeyCallback returns the host key verification to use for the given auth method. When
+// neither a known_hosts file or pinned fingerprints are given, host keys are not verified.
+func hostKeyCallback(auth *AuthMethod) (ssh.HostKeyCallback, error) {
+	if len(auth.HostKeyFingerprints) > 0 {
+		return pinnedHostKeys(auth.HostKeyFingerprints), nil
+	}
+
+	if len(auth.KnownHostsFile) > 0 {
+		return knownHostKeys(auth.KnownHostsFile, auth.TrustOnFirstUse)
+	}
+
+	return ssh.InsecureIgnoreHostKey(), nil
+}
+
+// pinnedHostKeys only accepts a host key whose fingerprint is one of the given ones. Both
+// the SHA256 ("SHA256:...") and legacy MD5 ("aa:bb:...") fingerprint formats are accepted.
+func pinnedHostKeys(fingerprints []string) ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		sha := ssh.FingerprintSHA256(key)
+		md5 := ssh.FingerprintLegacyMD5(key)
+
+		for _, f := range fingerprints {
+			f = strings.TrimSpace(f)
+			if f == sha || strings.TrimPrefix(f, "MD5:") == md5 {
+				return nil
+			}
+		}
+
+		return &HostKeyError{
+			Host:        hostname,
+			Fingerprint: sha,
+			Want:        fingerprints,
+		}
+	}
+}
+
+// knownHostKeys verifies host keys against an OpenSSH known_hosts file. If tofu is set, hosts
+// that aren't in the file yet are trusted and added to it.
+func knownHostKeys(file string, tofu bool) (ssh.HostKeyCallback, error) {
+	if _, err := os.Stat(file); err != nil {
+		if !tofu || !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		tofuMu.Lock()
+		defer tofuMu.Unlock()
+
+		err := errors.New("no known_hosts file")
+		if _, serr := os.Stat(file); serr == nil {
+			check, kerr := knownhosts.New(file)
+			if kerr != nil {
+				return kerr
+			}
+			err = check(hostname, remote, key)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		switch e := err.(type) {
+		case *knownhosts.RevokedError:
+			return &HostKeyError{Host: hostname, Fingerprint: ssh.FingerprintSHA256(key), Revoked: true}
+		case *knownhosts.KeyError:
+			if len(e.Want) > 0 || !tofu {
+				want := make([]string, 0, len(e.Want))
+				for _, k := range e.Want {
+					want = append(want, ssh.FingerprintSHA256(k.Key))
+				}
+
+				return &HostKeyError{Host: hostname, Fingerprint: ssh.FingerprintSHA256(key), Want: want}
+			}
+		default:
+			if !tofu {
+				return err
+			}
+		}
+
+		return trustHostKey(file, hostname, key)
+	}, nil
+}
+
+// trustHostKey appends the host key to the known_hosts file.
+func trustHostKey(file, hostname string, key ssh.PublicKey) error {
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(f, knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// verifyHostAs wraps the host key callback in config so it's given host as the hostname to
+// verify, rather than the remote address of the connection. The returned function reports
+// the last host key error seen, if any.
+func verifyHostAs(config *ssh.ClientConfig, host string) (*ssh.ClientConfig, func() *HostKeyError) {
+	var hkErr *HostKeyError
+	cb := config.HostKeyCallback
+	if cb == nil {
+		return config, func() *HostKeyError { return nil }
+	}
+
+	c := *config
+	c.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := cb(netconfAddr(host), remote, key)
+		if e, ok := err.(*HostKeyError); ok {
+			hkErr = e
+		}
+
+		return err
+	}
+
+	return &c, func() *HostKeyError { return hkErr }
+}
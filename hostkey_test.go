@@ -0,0 +1,116 @@
+package junos_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// credentials returns an auth method for the server that doesn't verify its host key.
+func credentials(srv *junostest.Server) *junos.AuthMethod {
+	return &junos.AuthMethod{Credentials: []string{srv.Username, srv.Password}}
+}
+
+func randomHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func TestHostKeyFingerprints(t *testing.T) {
+	srv := junostest.NewServer()
+	defer srv.Close()
+
+	auth := credentials(srv)
+	auth.HostKeyFingerprints = []string{ssh.FingerprintSHA256(srv.HostKey())}
+
+	j, err := junos.NewSession(srv.Addr, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	auth.HostKeyFingerprints = []string{ssh.FingerprintSHA256(randomHostKey(t))}
+	if _, err := junos.NewSession(srv.Addr, auth); err == nil {
+		t.Fatal("NewSession accepted a host key that doesn't match the pinned fingerprint")
+	} else if _, ok := err.(*junos.HostKeyError); !ok {
+		t.Fatalf("NewSession returned %T (%v), want *HostKeyError", err, err)
+	}
+}
+
+func TestHostKeyTrustOnFirstUse(t *testing.T) {
+	srv := junostest.NewServer()
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "known_hosts")
+
+	auth := credentials(srv)
+	auth.KnownHostsFile = file
+
+	_, err := junos.NewSession(srv.Addr, auth)
+	if err == nil {
+		t.Fatal("NewSession accepted a missing known_hosts file")
+	}
+
+	auth.TrustOnFirstUse = true
+	for i := 0; i < 2; i++ {
+		j, err := junos.NewSession(srv.Addr, auth)
+		if err != nil {
+			t.Fatalf("connection %d: %v", i+1, err)
+		}
+		j.Close()
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := knownhosts.Line([]string{knownhosts.Normalize(srv.Addr)}, srv.HostKey()) + "\n"
+	if string(data) != want {
+		t.Errorf("known_hosts is %q, want %q", data, want)
+	}
+}
+
+func TestHostKeyMismatch(t *testing.T) {
+	srv := junostest.NewServer()
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "known_hosts")
+
+	line := knownhosts.Line([]string{knownhosts.Normalize(srv.Addr)}, randomHostKey(t))
+	if err := ioutil.WriteFile(file, []byte(line+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	auth := credentials(srv)
+	auth.KnownHostsFile = file
+	auth.TrustOnFirstUse = true
+
+	_, err := junos.NewSession(srv.Addr, auth)
+	hkerr, ok := err.(*junos.HostKeyError)
+	if !ok {
+		t.Fatalf("NewSession returned %T (%v), want *HostKeyError", err, err)
+	}
+
+	if hkerr.Fingerprint != ssh.FingerprintSHA256(srv.HostKey()) || len(hkerr.Want) != 1 {
+		t.Errorf("HostKeyError is %+v", hkerr)
+	}
+}
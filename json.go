@@ -0,0 +1,296 @@
+package junos
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// jsonReply returns the JSON in a reply to an RPC run with format="json". The JSON is the
+// text of the reply, so it's XML escaped, and some releases wrap it in an element.
+func jsonReply(data string) (string, error) {
+	d := xml.NewDecoder(strings.NewReader("<reply>" + data + "</reply>"))
+
+	var buf bytes.Buffer
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("error reading JSON reply - %s", err)
+		}
+
+		if cd, ok := tok.(xml.CharData); ok {
+			buf.Write(cd)
+		}
+	}
+
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// isJSON returns whether an RPC reply is JSON, rather than XML.
+func isJSON(data string) bool {
+	data = strings.TrimSpace(data)
+
+	return strings.HasPrefix(data, "{") || strings.HasPrefix(data, "[")
+}
+
+// DecodeJSON decodes the JSON returned by Junos (e.g. by GetConfig or Command with the json
+// format) into v, which is anything encoding/json can decode into. It smooths over the ways
+// Junos JSON differs from the JSON you'd expect:
+//
+// Leaves in operational output, e.g. "host-name": [{"data": "fw1"}], are decoded as their
+// value ("fw1"). Empty elements, e.g. "disable": [null], are decoded as true. Attributes,
+// e.g. junos:seconds, are moved to an "@" key in the element's object (or "@name" alongside a
+// leaf called name), which is where they are in configuration output.
+//
+// Since Junos sends every element that can repeat as an array, even when there's only one
+// of it, arrays with a single item are decoded into struct fields and values that aren't
+// slices, and single values into slices. Numbers sent as strings are decoded into numeric
+// fields.
+func DecodeJSON(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("DecodeJSON requires a non-nil pointer, not %T", v)
+	}
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var raw interface{}
+	if err := d.Decode(&raw); err != nil {
+		return fmt.Errorf("error decoding JSON - %s", err)
+	}
+
+	shaped := shapeJSON(normalizeJSON(raw), rv.Type().Elem())
+
+	b, err := json.Marshal(shaped)
+	if err != nil {
+		return fmt.Errorf("error decoding JSON - %s", err)
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("error decoding JSON - %s", err)
+	}
+
+	return nil
+}
+
+// normalizeJSON rewrites the Junos specific parts of the JSON (see DecodeJSON).
+func normalizeJSON(x interface{}) interface{} {
+	switch t := x.(type) {
+	case []interface{}:
+		if len(t) == 1 && t[0] == nil {
+			return true
+		}
+
+		out := make([]interface{}, len(t))
+		for i, item := range t {
+			out[i] = normalizeJSON(item)
+		}
+
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			if k == "attributes" {
+				out["@"] = v
+				continue
+			}
+
+			if values, attrs, ok := jsonLeaf(v); ok {
+				out[k] = values
+				if attrs != nil {
+					out["@"+k] = attrs
+				}
+				continue
+			}
+
+			out[k] = normalizeJSON(v)
+		}
+
+		return out
+	}
+
+	return x
+}
+
+// jsonLeaf returns the value (or values) of a leaf in operational output, which is an array
+// of {"data": value, "attributes": {...}} objects, along with the attributes of the first.
+func jsonLeaf(x interface{}) (interface{}, interface{}, bool) {
+	items, ok := x.([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, nil, false
+	}
+
+	var values []interface{}
+	var attrs interface{}
+
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, nil, false
+		}
+
+		data, ok := m["data"]
+		if !ok {
+			return nil, nil, false
+		}
+
+		for k := range m {
+			if k != "data" && k != "attributes" {
+				return nil, nil, false
+			}
+		}
+
+		values = append(values, data)
+		if i == 0 {
+			attrs = m["attributes"]
+		}
+	}
+
+	if len(values) == 1 {
+		return values[0], attrs, true
+	}
+
+	return values, attrs, true
+}
+
+// shapeJSON reshapes x to suit being decoded into a value of type t, by unwrapping and
+// wrapping single items in arrays, and converting numbers to and from strings.
+func shapeJSON(x interface{}, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if x == nil || reflect.PtrTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return x
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return x
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return x
+		}
+
+		items, ok := x.([]interface{})
+		if !ok {
+			items = []interface{}{x}
+		}
+
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			out[i] = shapeJSON(item, t.Elem())
+		}
+
+		return out
+	}
+
+	if items, ok := x.([]interface{}); ok {
+		if len(items) == 0 {
+			return nil
+		}
+		x = items[0]
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := x.(map[string]interface{})
+		if !ok {
+			return x
+		}
+
+		out := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			if ft, ok := jsonFieldType(t, k); ok {
+				out[k] = shapeJSON(v, ft)
+			} else {
+				out[k] = v
+			}
+		}
+
+		return out
+	case reflect.Map:
+		m, ok := x.(map[string]interface{})
+		if !ok {
+			return x
+		}
+
+		out := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			out[k] = shapeJSON(v, t.Elem())
+		}
+
+		return out
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if s, ok := x.(string); ok {
+			s = strings.TrimSpace(s)
+			if _, err := strconv.ParseFloat(s, 64); err == nil {
+				return json.Number(s)
+			}
+		}
+	case reflect.String:
+		switch v := x.(type) {
+		case json.Number:
+			return v.String()
+		case bool:
+			return strconv.FormatBool(v)
+		}
+	}
+
+	return x
+}
+
+// jsonFieldType returns the type of the field in struct type t that the JSON key decodes
+// into, following the same rules as encoding/json.
+func jsonFieldType(t reflect.Type, key string) (reflect.Type, bool) {
+	var fold reflect.Type
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := f.Name
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		if n := strings.Split(tag, ",")[0]; n != "" {
+			name = n
+		}
+
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		if f.Anonymous && tag == "" && ft.Kind() == reflect.Struct {
+			if et, ok := jsonFieldType(ft, key); ok {
+				return et, true
+			}
+			continue
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		if name == key {
+			return f.Type, true
+		}
+		if fold == nil && strings.EqualFold(name, key) {
+			fold = f.Type
+		}
+	}
+
+	return fold, fold != nil
+}
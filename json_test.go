@@ -0,0 +1,135 @@
+package junos_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+// versionJSON is the output of "show version | display json".
+const versionJSON = `{"software-information":[{"host-name":[{"data":"fw1"}],"product-model":[{"data":"srx300"}],` +
+	`"package-information":[{"name":[{"data":"junos"}],"comment":[{"data":"JUNOS & co"}]}],` +
+	`"up-time":[{"data":"1 day","attributes":{"junos:seconds":"86400"}}],"count":[{"data":"42"}]}]}`
+
+// configJSON is the output of "show configuration | display json".
+const configJSON = `{"configuration":{"@":{"junos:commit-seconds":"1571"},"system":{"host-name":"fw1",` +
+	`"services":{"ssh":[null]},"name-server":[{"name":"8.8.8.8"}]},` +
+	`"interfaces":{"interface":[{"name":"ge-0/0/0","disable":[null],"@name":{"junos:changed":"x"}}]}}}`
+
+type versionReply struct {
+	Software struct {
+		Hostname string `json:"host-name"`
+		Model    string `json:"product-model"`
+		Count    int    `json:"count"`
+		Packages []struct {
+			Name    string `json:"name"`
+			Comment string `json:"comment"`
+		} `json:"package-information"`
+		Uptime      string `json:"up-time"`
+		UptimeAttrs struct {
+			Seconds string `json:"junos:seconds"`
+		} `json:"@up-time"`
+	} `json:"software-information"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	var v versionReply
+	if err := junos.DecodeJSON([]byte(versionJSON), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	sw := v.Software
+	if sw.Hostname != "fw1" || sw.Count != 42 || sw.Uptime != "1 day" || sw.UptimeAttrs.Seconds != "86400" {
+		t.Errorf("got %+v", sw)
+	}
+
+	if len(sw.Packages) != 1 || sw.Packages[0].Comment != "JUNOS & co" {
+		t.Errorf("got packages %+v", sw.Packages)
+	}
+
+	var m map[string]interface{}
+	if err := junos.DecodeJSON([]byte(versionJSON), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := m["software-information"]; !ok {
+		t.Errorf("got %v", m)
+	}
+}
+
+func TestDecodeJSONConfiguration(t *testing.T) {
+	var config struct {
+		Configuration struct {
+			System struct {
+				Hostname string `json:"host-name"`
+				Services struct {
+					SSH bool `json:"ssh"`
+				} `json:"services"`
+			} `json:"system"`
+			Interfaces struct {
+				Interface []struct {
+					Name    string `json:"name"`
+					Disable bool   `json:"disable"`
+				} `json:"interface"`
+			} `json:"interfaces"`
+		} `json:"configuration"`
+	}
+	if err := junos.DecodeJSON([]byte(configJSON), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	c := config.Configuration
+	if c.System.Hostname != "fw1" || !c.System.Services.SSH {
+		t.Errorf("got system %+v", c.System)
+	}
+
+	if len(c.Interfaces.Interface) != 1 || !c.Interfaces.Interface[0].Disable {
+		t.Errorf("got interfaces %+v", c.Interfaces)
+	}
+}
+
+func TestCommandJSON(t *testing.T) {
+	j, srv := newSession(t)
+	srv.HandleFunc("command", func(*junostest.Request) string {
+		return strings.NewReplacer("&", "&amp;", `"`, "&quot;").Replace(versionJSON)
+	})
+	srv.Reset()
+
+	out, err := j.Command("show version", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out != versionJSON {
+		t.Errorf("got %q", out)
+	}
+
+	if reqs := srv.Requests(); len(reqs) != 1 || reqs[0].Attrs["format"] != "json" {
+		t.Errorf("got requests %+v", reqs)
+	}
+
+	var v versionReply
+	if err := j.RPC(`<command format="json">show version</command>`, &v); err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Software.Hostname != "fw1" {
+		t.Errorf("got %+v", v)
+	}
+}
+
+func TestGetConfigJSON(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Handle("get-configuration", "\n"+configJSON+"\n")
+
+	config, err := j.GetConfig("json", "system")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config != configJSON {
+		t.Errorf("got %q", config)
+	}
+}
@@ -0,0 +1,96 @@
+package junos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// JumpHost is an SSH bastion that the NETCONF session is tunnelled through, the same as
+// OpenSSH's ProxyJump. Host defaults to port 22 if one isn't given, and Auth defines how
+// we authenticate to the jump host itself.
+type JumpHost struct {
+	Host string
+	Auth *AuthMethod
+}
+
+// dialJumpHosts connects through each of the jump hosts in turn, and returns a connection
+// to addr tunnelled through the last one, along with the SSH clients for each hop. The
+// clients must be closed, in reverse order, once the connection is no longer needed.
+func dialJumpHosts(ctx context.Context, hops []JumpHost, addr string) (net.Conn, []io.Closer, error) {
+	var clients []io.Closer
+	var client *ssh.Client
+
+	fail := func(err error) (net.Conn, []io.Closer, error) {
+		closeAll(clients)
+		return nil, nil, err
+	}
+
+	for _, hop := range hops {
+		if hop.Auth == nil {
+			return fail(fmt.Errorf("no authentication method given for jump host %s", hop.Host))
+		}
+
+		hopAddr := hop.Host
+		if _, _, err := net.SplitHostPort(hopAddr); err != nil {
+			hopAddr = net.JoinHostPort(hopAddr, "22")
+		}
+
+		var nc net.Conn
+		var err error
+		if client == nil {
+			var d net.Dialer
+			nc, err = d.DialContext(ctx, "tcp", hopAddr)
+		} else {
+			nc, err = client.Dial("tcp", hopAddr)
+		}
+		if err != nil {
+			return fail(fmt.Errorf("error connecting to jump host %s - %s", hop.Host, err))
+		}
+
+		client, err = newSSHClient(ctx, nc, hopAddr, hop.Auth)
+		if err != nil {
+			return fail(err)
+		}
+
+		clients = append(clients, client)
+	}
+
+	nc, err := client.Dial("tcp", addr)
+	if err != nil {
+		return fail(fmt.Errorf("error connecting to %s through jump host - %s", addr, err))
+	}
+
+	return nc, clients, nil
+}
+
+// newSSHClient establishes an SSH client connection over nc, giving up once ctx is done.
+func newSSHClient(ctx context.Context, nc net.Conn, addr string, auth *AuthMethod) (*ssh.Client, error) {
+	config, release, err := genSSHClientConfig(auth)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	defer release()
+
+	client, err := sshHandshake(ctx, nc, addr, config)
+	if err != nil {
+		if _, ok := err.(*HostKeyError); ok {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("error connecting to jump host %s - %s", addr, err)
+	}
+
+	return client, nil
+}
+
+// closeAll closes each of the given closers, in reverse order.
+func closeAll(closers []io.Closer) {
+	for i := len(closers) - 1; i >= 0; i-- {
+		closers[i].Close()
+	}
+}
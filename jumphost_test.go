@@ -0,0 +1,192 @@
+package junos_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+	"golang.org/x/crypto/ssh"
+)
+
+// bastion is an SSH server that only forwards connections (i.e. direct-tcpip channels),
+// like a jump host. Open is the number of SSH connections it has open.
+type bastion struct {
+	addr string
+	open int32
+}
+
+func newBastion(t *testing.T) *bastion {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if c.User() == "jump" && string(password) == "jump" {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("invalid credentials for %s", c.User())
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	b := &bastion{addr: l.Addr().String()}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			go b.serve(c, config)
+		}
+	}()
+
+	return b
+}
+
+func (b *bastion) serve(c net.Conn, config *ssh.ServerConfig) {
+	sc, chans, reqs, err := ssh.NewServerConn(c, config)
+	if err != nil {
+		return
+	}
+
+	atomic.AddInt32(&b.open, 1)
+	defer atomic.AddInt32(&b.open, -1)
+
+	go ssh.DiscardRequests(reqs)
+	go func() {
+		for nc := range chans {
+			go forward(nc)
+		}
+	}()
+
+	sc.Wait()
+}
+
+// forward connects a direct-tcpip channel to the address it asks for.
+func forward(nc ssh.NewChannel) {
+	var target struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+
+	if nc.ChannelType() != "direct-tcpip" || ssh.Unmarshal(nc.ExtraData(), &target) != nil {
+		nc.Reject(ssh.UnknownChannelType, "only direct-tcpip is supported")
+		return
+	}
+
+	tc, err := net.Dial("tcp", net.JoinHostPort(target.Host, fmt.Sprint(target.Port)))
+	if err != nil {
+		nc.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+
+	ch, reqs, err := nc.Accept()
+	if err != nil {
+		tc.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	go func() {
+		io.Copy(ch, tc)
+		ch.Close()
+	}()
+	go func() {
+		io.Copy(tc, ch)
+		tc.Close()
+	}()
+}
+
+// Open returns the number of SSH connections the bastion has open.
+func (b *bastion) Open() int {
+	return int(atomic.LoadInt32(&b.open))
+}
+
+func TestProxyJump(t *testing.T) {
+	srv := newServer(t)
+	b1, b2 := newBastion(t), newBastion(t)
+
+	jump := &junos.AuthMethod{Credentials: []string{"jump", "jump"}}
+	auth := srv.Auth()
+	auth.ProxyJump = []junos.JumpHost{{Host: b1.addr, Auth: jump}, {Host: b2.addr, Auth: jump}}
+
+	j, err := junos.NewSession(srv.Addr, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := j.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	if b1.Open() != 1 || b2.Open() != 1 {
+		t.Errorf("the jump hosts have %d and %d connections open, want 1 each", b1.Open(), b2.Open())
+	}
+
+	j.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for (b1.Open() != 0 || b2.Open() != 0) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if b1.Open() != 0 || b2.Open() != 0 {
+		t.Errorf("the jump hosts still have %d and %d connections open after Close", b1.Open(), b2.Open())
+	}
+}
+
+func TestProxyJumpFactsError(t *testing.T) {
+	srv := newServer(t)
+	srv.Handle("get-software-information", junostest.RPCError("protocol", "operation-failed", "error", "permission denied"))
+	b := newBastion(t)
+
+	auth := srv.Auth()
+	auth.ProxyJump = []junos.JumpHost{{Host: b.addr, Auth: &junos.AuthMethod{Credentials: []string{"jump", "jump"}}}}
+
+	j, err := junos.NewSession(srv.Addr, auth)
+	if err == nil {
+		t.Fatal("NewSession through a jump host ignored the error gathering facts")
+	}
+	if j != nil {
+		j.Close()
+	}
+}
+
+func TestProxyJumpBadCredentials(t *testing.T) {
+	srv := newServer(t)
+	b := newBastion(t)
+
+	auth := srv.Auth()
+	auth.ProxyJump = []junos.JumpHost{{Host: b.addr, Auth: &junos.AuthMethod{Credentials: []string{"jump", "wrong"}}}}
+
+	if _, err := junos.NewSession(srv.Addr, auth); err == nil {
+		t.Error("NewSession succeeded through a jump host it couldn't log in to")
+	}
+}
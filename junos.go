@@ -3,16 +3,20 @@
 package junos
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
-	"regexp"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 
 	"github.com/Juniper/go-netconf/netconf"
 )
@@ -21,23 +25,12 @@ import (
 var (
 	rpcCommand             = "<command format=\"text\">%s</command>"
 	rpcCommandXML          = "<command format=\"xml\">%s</command>"
-	rpcCommit              = "<commit-configuration/>"
-	rpcCommitAt            = "<commit-configuration><at-time>%s</at-time></commit-configuration>"
-	rpcCommitAtLog         = "<commit-configuration><at-time>%s</at-time><log>%s</log></commit-configuration>"
-	rpcCommitCheck         = "<commit-configuration><check/></commit-configuration>"
-	rpcCommitConfirm       = "<commit-configuration><confirmed/><confirm-timeout>%d</confirm-timeout></commit-configuration>"
-	rpcCommitFull          = "<commit-configuration><full/></commit-configuration>"
+	rpcCommandJSON         = "<command format=\"json\">%s</command>"
 	rpcFactsRE             = "<get-route-engine-information/>"
 	rpcFactsChassis        = "<get-chassis-inventory/>"
-	rpcConfigFileSet       = "<load-configuration action=\"set\" format=\"text\"><configuration-set>%s</configuration-set></load-configuration>"
-	rpcConfigFileText      = "<load-configuration format=\"text\"><configuration-text>%s</configuration-text></load-configuration>"
-	rpcConfigFileXML       = "<load-configuration format=\"xml\"><configuration>%s</configuration></load-configuration>"
-	rpcConfigURLSet        = "<load-configuration action=\"set\" format=\"text\" url=\"%s\"/>"
-	rpcConfigURLText       = "<load-configuration format=\"text\" url=\"%s\"/>"
-	rpcConfigURLXML        = "<load-configuration format=\"xml\" url=\"%s\"/>"
-	rpcConfigStringSet     = "<load-configuration action=\"set\" format=\"text\"><configuration-set>%s</configuration-set></load-configuration>"
-	rpcConfigStringText    = "<load-configuration action=\"replace\" format=\"text\"><configuration-text>%s</configuration-text></load-configuration>"
-	rpcConfigStringXML     = "<load-configuration format=\"xml\"><configuration>%s</configuration></load-configuration>"
+	rpcFactsDomain         = "<get-configuration database=\"committed\"><configuration><system><domain-name/></system></configuration></get-configuration>"
+	rpcFactsVirtualChassis = "<get-virtual-chassis-information/>"
+	rpcFactsCluster        = "<get-chassis-cluster-status/>"
 	rpcGetRescue           = "<get-rescue-information><format>text</format></get-rescue-information>"
 	rpcGetRollback         = "<get-rollback-information><rollback>%d</rollback><format>text</format></get-rollback-information>"
 	rpcGetRollbackCompare  = "<get-rollback-information><rollback>0</rollback><compare>%d</compare><format>text</format></get-rollback-information>"
@@ -56,6 +49,7 @@ var (
 	rpcCommitHistory       = "<get-commit-information/>"
 	rpcFileList            = "<file-list><detail/><path>%s</path></file-list>"
 	rpcInterfaces          = "<get-interface-information/>"
+	rpcPing                = "<get-system-uptime-information/>"
 )
 
 // Junos contains our session state.
@@ -65,6 +59,26 @@ type Junos struct {
 	RoutingEngines int
 	Platform       []RoutingEngine
 	CommitTimeout  time.Duration
+	WarningPolicy  WarningPolicy
+	Capabilities   []Capability
+	Facts          *Facts
+	MinimalFacts   bool
+	RateLimit      *RateLimiter
+	RetryPolicy    *RetryPolicy
+
+	// mu serializes RPCs on the session. connMu guards swapping the session (and its
+	// jump host tunnels) on reconnect, so it can be closed while an RPC is pending.
+	mu         sync.Mutex
+	connMu     sync.Mutex
+	closers    []io.Closer
+	redial     func(ctx context.Context, cfg sessionConfig) (*Junos, error)
+	rc         *reconnector
+	recording  *recording
+	fleetLimit *RateLimiter
+
+	// configMu guards configSession, the configuration database that's open on the session.
+	configMu      sync.Mutex
+	configSession *ConfigSession
 }
 
 // AuthMethod defines how we want to authenticate to the device. If using a
@@ -79,11 +93,44 @@ type Junos struct {
 // ~/.ssh/id_rsa
 //
 // If you do not have a passphrase tied to your private key, then you can omit this field.
+// If you have the private key itself rather than a file, e.g. from a secrets manager, put
+// the PEM encoded key in PrivateKeyData instead of PrivateKey.
+//
+// Setting UseAgent authenticates using the keys held by the running ssh-agent (found via the
+// SSH_AUTH_SOCK environment variable). KeyboardInteractive answers keyboard-interactive
+// challenges, such as those used by TACACS+ backed devices. If it isn't set, but a password
+// is given in Credentials, any keyboard-interactive prompts are answered with that password.
+//
+// If the device can only be reached through one or more SSH bastions, list them in
+// ProxyJump. The NETCONF session is tunnelled through each of them in order, and the
+// tunnels are torn down when the session is closed.
+//
+// When more than one method is configured, they are tried in the following order until
+// one succeeds: ssh-agent, private key, password, keyboard-interactive. The username is
+// taken from Username, or from Credentials if Username is empty.
+//
+// To verify the device's host key, either give the path to an OpenSSH known_hosts file in
+// KnownHostsFile, or pin the key to one or more fingerprints (as printed by "ssh-keygen -l")
+// in HostKeyFingerprints. If TrustOnFirstUse is set, devices that aren't in the known_hosts
+// file yet are added to it, rather than rejected. When none of these are set, the host key
+// is not verified. A host key that fails verification results in a *HostKeyError.
+//
+// Setting MinimalFacts skips gathering the facts that take an RPC of their own (see Facts),
+// which speeds up connecting to large numbers of devices. It's the AuthMethod's equivalent of
+// the GatherMinimalFacts option, and sets MinimalFacts on the session.
 type AuthMethod struct {
-	Credentials []string
-	Username    string
-	PrivateKey  string
-	Passphrase  string
+	Credentials         []string
+	Username            string
+	PrivateKey          string
+	Passphrase          string
+	PrivateKeyData      []byte
+	UseAgent            bool
+	KeyboardInteractive ssh.KeyboardInteractiveChallenge
+	KnownHostsFile      string
+	HostKeyFingerprints []string
+	TrustOnFirstUse     bool
+	ProxyJump           []JumpHost
+	MinimalFacts        bool
 }
 
 // CommitHistory holds all of the commit entries.
@@ -102,37 +149,53 @@ type CommitEntry struct {
 }
 
 // RoutingEngine contains the hardware and software information for each route engine.
+// ParsedVersion is Version parsed, for comparing against, e.g.:
+//
+//	if ok, _ := re.ParsedVersion.Satisfies(">= 15.1X49"); ok {
 type RoutingEngine struct {
-	Model   string
-	Version string
+	Model         string
+	Version       string
+	ParsedVersion Version
 }
 
 type commandXML struct {
 	Config string `xml:",innerxml"`
 }
 
-type commitError struct {
-	Path    string `xml:"error-path"`
-	Element string `xml:"error-info>bad-element"`
-	Message string `xml:"error-message"`
+// LoadResult holds the outcome of loading configuration, along with the warnings the device
+// reported. If the configuration was also committed, Commit holds the outcome of the commit.
+type LoadResult struct {
+	Warnings []*RPCError
+	Commit   *CommitResult
+}
+
+// CommitResult holds the outcome of a commit, along with the warnings the device reported.
+type CommitResult struct {
+	Warnings []*RPCError
+}
+
+// commitReply is the reply to a commit-configuration RPC, wrapped in a root element.
+type commitReply struct {
+	Results   []*RPCError `xml:"commit-results>rpc-error"`
+	REResults []*RPCError `xml:"commit-results>routing-engine>rpc-error"`
 }
 
-type commitResults struct {
-	XMLName xml.Name      `xml:"commit-results"`
-	Errors  []commitError `xml:"rpc-error"`
+// loadReply is the reply to a load-configuration RPC, wrapped in a root element.
+type loadReply struct {
+	Results []*RPCError `xml:"load-configuration-results>rpc-error"`
 }
 
 type diffXML struct {
-	XMLName xml.Name `xml:"rollback-information"`
-	Error   string   `xml:"rpc-error>error-message"`
-	Config  string   `xml:"configuration-information>configuration-output"`
+	XMLName xml.Name    `xml:"rollback-information"`
+	Errors  []*RPCError `xml:"rpc-error"`
+	Config  string      `xml:"configuration-information>configuration-output"`
 }
 
 // cdiffXML - candidate config diff XML
 type cdiffXML struct {
-	XMLName xml.Name `xml:"configuration-information"`
-	Error   string   `xml:"rpc-error>error-message"`
-	Config  string   `xml:"configuration-output"`
+	XMLName xml.Name    `xml:"configuration-information"`
+	Errors  []*RPCError `xml:"rpc-error"`
+	Config  string      `xml:"configuration-output"`
 }
 
 type hardwareRouteEngines struct {
@@ -155,39 +218,136 @@ type versionRouteEngine struct {
 	XMLName     xml.Name             `xml:"software-information"`
 	Hostname    string               `xml:"host-name"`
 	Platform    string               `xml:"product-model"`
+	Version     string               `xml:"junos-version"`
 	PackageInfo []versionPackageInfo `xml:"package-information"`
 }
 
+// routingEngine returns the model and version of the routing engine. The version is taken
+// from the package comment (e.g. "JUNOS Software Release [18.4R2-S3]") on releases that
+// don't report it on its own.
+func (v versionRouteEngine) routingEngine() RoutingEngine {
+	re := RoutingEngine{Model: strings.ToUpper(v.Platform), Version: strings.TrimSpace(v.Version)}
+	if re.Version == "" && len(v.PackageInfo) > 0 && len(v.PackageInfo[0].SoftwareVersion) > 0 {
+		re.Version = findVersion(v.PackageInfo[0].SoftwareVersion[0])
+	}
+
+	re.ParsedVersion, _ = ParseVersion(re.Version)
+
+	return re
+}
+
 type versionPackageInfo struct {
 	XMLName         xml.Name `xml:"package-information"`
 	PackageName     []string `xml:"name"`
 	SoftwareVersion []string `xml:"comment"`
 }
 
-// genSSHClientConfig is a wrapper function based around the auth method defined
-// (user/password or private key) which returns the SSH client configuration used to
-// connect.
-func genSSHClientConfig(auth *AuthMethod) (*ssh.ClientConfig, error) {
-	var config *ssh.ClientConfig
-
+// genSSHClientConfig is a wrapper function based around the auth methods defined
+// (ssh-agent, private key, user/password or keyboard-interactive) which returns the SSH
+// client configuration used to connect. The returned function releases any resources
+// held by the configuration (i.e. the ssh-agent connection), and must be called once
+// the connection has been established.
+func genSSHClientConfig(auth *AuthMethod) (*ssh.ClientConfig, func(), error) {
+	var methods []ssh.AuthMethod
+	var password string
+	release := func() {}
+
+	user := auth.Username
 	if len(auth.Credentials) > 0 {
-		config = netconf.SSHConfigPassword(auth.Credentials[0], auth.Credentials[1])
+		if len(auth.Credentials) < 2 {
+			return nil, release, errors.New("credentials must contain both a username and password")
+		}
 
-		return config, nil
+		if user == "" {
+			user = auth.Credentials[0]
+		}
+		password = auth.Credentials[1]
 	}
 
+	hostKeys, err := hostKeyCallback(auth)
+	if err != nil {
+		return nil, release, err
+	}
+
+	if auth.UseAgent {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return nil, release, errors.New("ssh-agent requested, but SSH_AUTH_SOCK is not set")
+		}
+
+		conn, err := net.Dial("unix", sock)
+		if err != nil {
+			return nil, release, fmt.Errorf("error connecting to ssh-agent - %s", err)
+		}
+
+		release = func() { conn.Close() }
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+
+	keyData := auth.PrivateKeyData
 	if len(auth.PrivateKey) > 0 {
-		config, err := netconf.SSHConfigPubKeyFile(auth.Username, auth.PrivateKey, auth.Passphrase)
+		keyData, err = ioutil.ReadFile(auth.PrivateKey)
 		if err != nil {
-			return config, err
+			release()
+			return nil, func() {}, err
 		}
+	}
+
+	if len(keyData) > 0 {
+		signer, err := parsePrivateKey(keyData, auth.Passphrase)
+		if err != nil {
+			release()
+			return nil, func() {}, err
+		}
+
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if len(password) > 0 {
+		methods = append(methods, ssh.Password(password))
+	}
+
+	switch {
+	case auth.KeyboardInteractive != nil:
+		methods = append(methods, ssh.KeyboardInteractive(auth.KeyboardInteractive))
+	case len(password) > 0:
+		methods = append(methods, ssh.KeyboardInteractive(answerWith(password)))
+	}
+
+	if len(methods) == 0 {
+		return nil, release, errors.New("no credentials/keys available")
+	}
 
-		config.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+	config := &ssh.ClientConfig{
+		User:            user,
+		Auth:            methods,
+		HostKeyCallback: hostKeys,
+	}
 
-		return config, nil
+	return config, release, nil
+}
+
+// parsePrivateKey parses a PEM encoded private key, decrypting it with the passphrase
+// if one is given.
+func parsePrivateKey(data []byte, passphrase string) (ssh.Signer, error) {
+	if len(passphrase) > 0 {
+		return ssh.ParsePrivateKeyWithPassphrase(data, []byte(passphrase))
 	}
 
-	return config, errors.New("no credentials/keys available")
+	return ssh.ParsePrivateKey(data)
+}
+
+// answerWith returns a keyboard-interactive challenge that answers every question with
+// the given password.
+func answerWith(password string) ssh.KeyboardInteractiveChallenge {
+	return func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		for i := range answers {
+			answers[i] = password
+		}
+
+		return answers, nil
+	}
 }
 
 // NewSession establishes a new connection to a Junos device that we will use
@@ -200,74 +360,271 @@ func genSSHClientConfig(auth *AuthMethod) (*ssh.ClientConfig, error) {
 //
 // NOTE: most users should use this function, instead of the other NewSession* functions
 func NewSession(host string, auth *AuthMethod) (*Junos, error) {
-	clientConfig, err := genSSHClientConfig(auth)
+	return NewSessionContext(context.Background(), host, auth)
+}
+
+// NewSessionContext is the same as NewSession, but gives up connecting to the device
+// once the given context is cancelled or its deadline passes.
+func NewSessionContext(ctx context.Context, host string, auth *AuthMethod) (*Junos, error) {
+	return newSession(ctx, host, auth, sessionConfig{minimalFacts: auth.MinimalFacts})
+}
+
+// newSession establishes the session for NewSessionContext, with the given options.
+func newSession(ctx context.Context, host string, auth *AuthMethod, cfg sessionConfig) (*Junos, error) {
+	clientConfig, release, err := genSSHClientConfig(auth)
 	if err != nil {
 		return nil, err
 	}
+	defer release()
+
+	var j *Junos
+	if len(auth.ProxyJump) == 0 {
+		j, err = newSessionWithConfig(ctx, host, clientConfig, cfg)
+	} else {
+		var nc net.Conn
+		var hops []io.Closer
+		nc, hops, err = dialJumpHosts(ctx, auth.ProxyJump, netconfAddr(host))
+		if err != nil {
+			return nil, err
+		}
+
+		j, err = newSessionFromNetConn(ctx, host, nc, clientConfig, cfg)
+		if j == nil {
+			closeAll(hops)
+			return nil, err
+		}
+		j.closers = hops
+	}
 
-	return NewSessionWithConfig(host, clientConfig)
+	if j != nil {
+		j.redial = func(ctx context.Context, cfg sessionConfig) (*Junos, error) {
+			return newSession(ctx, host, auth, cfg)
+		}
+	}
+
+	return j, err
 }
 
 // NewSessionWithConfig establishes a new connection to a Junos device that we will use
 // to run our commands against.
 //
 // This is especially useful if you need to customize the SSH connection beyond
-// what's supported in NewSession().
-func NewSessionWithConfig(host string, clientConfig *ssh.ClientConfig) (*Junos, error) {
-	s, err := netconf.DialSSH(host, clientConfig)
+// what's supported in NewSession(). See SessionOption for the options.
+func NewSessionWithConfig(host string, clientConfig *ssh.ClientConfig, options ...SessionOption) (*Junos, error) {
+	return NewSessionWithConfigContext(context.Background(), host, clientConfig, options...)
+}
+
+// NewSessionWithConfigContext is the same as NewSessionWithConfig, but gives up connecting
+// to the device once the given context is cancelled or its deadline passes.
+func NewSessionWithConfigContext(ctx context.Context, host string, clientConfig *ssh.ClientConfig, options ...SessionOption) (*Junos, error) {
+	return newSessionWithConfig(ctx, host, clientConfig, newSessionConfig(options))
+}
+
+// newSessionWithConfig establishes the session for NewSessionWithConfigContext, with the
+// given options.
+func newSessionWithConfig(ctx context.Context, host string, clientConfig *ssh.ClientConfig, cfg sessionConfig) (*Junos, error) {
+	d := net.Dialer{Timeout: clientConfig.Timeout}
+
+	nc, err := d.DialContext(ctx, "tcp", netconfAddr(host))
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error connecting to %s - %s", host, err))
+		return nil, fmt.Errorf("error connecting to %s - %s", host, err)
+	}
+
+	j, err := newSessionFromNetConn(ctx, host, nc, clientConfig, cfg)
+	if j != nil {
+		j.redial = func(ctx context.Context, cfg sessionConfig) (*Junos, error) {
+			return newSessionWithConfig(ctx, host, clientConfig, cfg)
+		}
 	}
 
-	return NewSessionFromNetconf(s)
+	return j, err
 }
 
 // NewSessionFromNetConn uses an existing net.Conn to establish a netconf.Session
 //
 // This is especially useful if you need to customize the SSH connection beyond
-// what's supported in NewSession().
-func NewSessionFromNetConn(host string, nc net.Conn, clientConfig *ssh.ClientConfig) (*Junos, error) {
-	s, err := netconf.NewSSHSession(nc, clientConfig)
+// what's supported in NewSession(). See SessionOption for the options.
+func NewSessionFromNetConn(host string, nc net.Conn, clientConfig *ssh.ClientConfig, options ...SessionOption) (*Junos, error) {
+	return NewSessionFromNetConnContext(context.Background(), host, nc, clientConfig, options...)
+}
 
+// NewSessionFromNetConnContext is the same as NewSessionFromNetConn, but gives up on the
+// SSH handshake once the given context is cancelled or its deadline passes. When that
+// happens, nc is closed.
+//
+// The host key presented by the device is verified as belonging to host. If that fails,
+// a *HostKeyError is returned.
+func NewSessionFromNetConnContext(ctx context.Context, host string, nc net.Conn, clientConfig *ssh.ClientConfig, options ...SessionOption) (*Junos, error) {
+	return newSessionFromNetConn(ctx, host, nc, clientConfig, newSessionConfig(options))
+}
+
+// newSessionFromNetConn establishes the session for NewSessionFromNetConnContext, with the
+// given options.
+func newSessionFromNetConn(ctx context.Context, host string, nc net.Conn, clientConfig *ssh.ClientConfig, cfg sessionConfig) (*Junos, error) {
+	s, err := dialSSH(ctx, host, nc, clientConfig)
 	if err != nil {
-		return nil, errors.New(fmt.Sprintf("error connecting to %s - %s", host, err))
+		if _, ok := err.(*HostKeyError); ok {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("error connecting to %s - %s", host, err)
 	}
 
-	return NewSessionFromNetconf(s)
+	return newSessionFromNetconf(ctx, s, cfg)
 }
 
 // NewSessionFromNetconf uses an existing netconf.Session to run our commands against
 //
 // This is especially useful if you need to customize the SSH connection beyond
-// what's supported in NewSession().
-func NewSessionFromNetconf(s *netconf.Session) (*Junos, error) {
+// what's supported in NewSession(). See SessionOption for the options.
+func NewSessionFromNetconf(s *netconf.Session, options ...SessionOption) (*Junos, error) {
+	return NewSessionFromNetconfContext(context.Background(), s, options...)
+}
+
+// NewSessionFromNetconfContext is the same as NewSessionFromNetconf, but gathers the
+// device facts using the given context.
+func NewSessionFromNetconfContext(ctx context.Context, s *netconf.Session, options ...SessionOption) (*Junos, error) {
+	return newSessionFromNetconf(ctx, s, newSessionConfig(options))
+}
+
+// newSessionFromNetconf sets up the session for NewSessionFromNetconfContext, with the given
+// options.
+func newSessionFromNetconf(ctx context.Context, s *netconf.Session, cfg sessionConfig) (*Junos, error) {
 	j := &Junos{
-		Session: s,
+		Session:      s,
+		Capabilities: parseCapabilities(s.ServerCapabilities),
+		MinimalFacts: cfg.minimalFacts,
+		RateLimit:    cfg.rateLimit,
+		RetryPolicy:  cfg.retryPolicy,
+		fleetLimit:   cfg.fleetLimit,
+	}
+
+	return j, j.GatherFactsContext(ctx)
+}
+
+// netconfAddr appends the default NETCONF port (830) to host, unless it already
+// specifies one.
+func netconfAddr(host string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+
+	return net.JoinHostPort(host, "830")
+}
+
+// exec sends the RPC to the device and waits for the reply, or until ctx is done. A NETCONF
+// session can't be used again once we stop waiting on a reply, so the session is closed
+// when that happens.
+//
+// If reconnects are enabled, a session whose transport has failed is re-established
+// before the RPC is sent. See EnableReconnect. If the session is rate limited, we wait
+// for the RPC to be allowed first.
+func (j *Junos) exec(ctx context.Context, rpc string) (*netconf.RPCReply, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	return j, j.GatherFacts()
+	if err := j.throttle(ctx); err != nil {
+		return nil, err
+	}
+
+	return j.execNow(ctx, rpc)
+}
+
+// execNow is the same as exec, but doesn't wait on the session's rate limits.
+func (j *Junos) execNow(ctx context.Context, rpc string) (*netconf.RPCReply, error) {
+	return j.execOn(ctx, nil, rpc)
+}
+
+// execOn is the same as execNow, but if s isn't nil, the RPC is only sent if s is still the
+// session, and its transport hasn't failed. Rather than re-establishing the session (see
+// EnableReconnect), errSessionReplaced is returned.
+func (j *Junos) execOn(ctx context.Context, s *netconf.Session, rpc string) (*netconf.RPCReply, error) {
+	j.mu.Lock()
+
+	if s != nil && (j.Session != s || (j.rc != nil && j.rc.dead)) {
+		j.mu.Unlock()
+		return nil, errSessionReplaced
+	}
+
+	reconnected := false
+	if j.rc != nil && j.rc.dead {
+		if err := j.reconnect(ctx); err != nil {
+			j.mu.Unlock()
+			return nil, err
+		}
+		reconnected = true
+	}
+
+	reply, err := j.execSession(ctx, rpc)
+	stripErrors(reply)
+	if _, ok := err.(*netconf.RPCError); ok {
+		err = j.replyError(reply)
+	} else if err != nil && j.rc != nil {
+		j.rc.dead = true
+	}
+
+	j.mu.Unlock()
+
+	if reconnected {
+		j.rc.reconnected(j)
+	}
+
+	return reply, err
+}
+
+// execSession runs the RPC on the current session.
+func (j *Junos) execSession(ctx context.Context, rpc string) (*netconf.RPCReply, error) {
+	s := j.Session
+
+	if ctx.Done() == nil {
+		return s.Exec(netconf.RawMethod(rpc))
+	}
+
+	type result struct {
+		reply *netconf.RPCReply
+		err   error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		reply, err := s.Exec(netconf.RawMethod(rpc))
+		done <- result{reply, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.reply, r.err
+	case <-ctx.Done():
+		j.closeTransport()
+		return nil, ctx.Err()
+	}
 }
 
-// GatherFacts gathers basic information about the device.
+// GatherFacts gathers basic information about the device, which is stored in Facts (along
+// with Hostname, RoutingEngines and Platform). If MinimalFacts is set, only the facts that
+// come with the software information are gathered.
 //
 // It's automatically called when using the provided NewSession* functions, but can be
 // used if you create your own Junos sessions.
 func (j *Junos) GatherFacts() error {
+	return j.GatherFactsContext(context.Background())
+}
+
+// GatherFactsContext is the same as GatherFacts, but stops waiting on the device once
+// the given context is done.
+func (j *Junos) GatherFactsContext(ctx context.Context) error {
 	if j == nil {
 		return errors.New("attempt to call GatherFacts on nil Junos object")
 	}
-	s := j.Session
-	rex := regexp.MustCompile(`^.*\[(.*)\]`)
 
-	reply, err := s.Exec(netconf.RawMethod(rpcVersion))
+	reply, err := j.exec(ctx, rpcVersion)
 	if err != nil {
 		return err
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
-		}
+	if err := j.replyError(reply); err != nil {
+		return err
 	}
 
 	formatted := strings.Replace(reply.Data, "\n", "", -1)
@@ -284,23 +641,13 @@ func (j *Junos) GatherFacts() error {
 		res := make([]RoutingEngine, 0, numRE)
 
 		for i := 0; i < numRE; i++ {
-			version := rex.FindStringSubmatch(facts.RE[i].PackageInfo[0].SoftwareVersion[0])
-			model := strings.ToUpper(facts.RE[i].Platform)
-
-			switch len(version) {
-			case 1:
-				res = append(res, RoutingEngine{Model: model, Version: version[0]})
-			case 2:
-				res = append(res, RoutingEngine{Model: model, Version: version[1]})
-			default:
-			}
+			res = append(res, facts.RE[i].routingEngine())
 		}
 
 		j.Hostname = hostname
 		j.RoutingEngines = numRE
 		j.Platform = res
-		j.CommitTimeout = 0
-		return nil
+		return j.gatherFacts(ctx, reply.Data)
 	}
 
 	var facts versionRouteEngine
@@ -312,25 +659,48 @@ func (j *Junos) GatherFacts() error {
 	// res := make([]RoutingEngine, 0)
 	var res []RoutingEngine
 	hostname := facts.Hostname
-	version := rex.FindStringSubmatch(facts.PackageInfo[0].SoftwareVersion[0])
-	model := strings.ToUpper(facts.Platform)
-	res = append(res, RoutingEngine{Model: model, Version: version[1]})
+	res = append(res, facts.routingEngine())
 
 	j.Hostname = hostname
 	j.RoutingEngines = 1
 	j.Platform = res
-	j.CommitTimeout = 0
-	return nil
+	return j.gatherFacts(ctx, reply.Data)
 }
 
-// Close disconnects our session to the device.
+// Close disconnects our session to the device, along with any jump host tunnels it
+// was using.
 func (j *Junos) Close() {
-	j.Session.Transport.Close()
+	j.connMu.Lock()
+	rc := j.rc
+	j.connMu.Unlock()
+
+	if rc != nil {
+		rc.close()
+	}
+
+	j.closeTransport()
+}
+
+// closeTransport closes the current session, and any jump host tunnels it was using.
+func (j *Junos) closeTransport() {
+	j.connMu.Lock()
+	s, closers := j.Session, j.closers
+	j.connMu.Unlock()
+
+	s.Transport.Close()
+	closeAll(closers)
 }
 
 // Command executes any operational mode command, such as "show" or "request." If you wish to return the results
-// of the command, specify the format, which must be "text" or "xml" as the second parameter (optional).
+// of the command, specify the format, which must be "text", "xml" or "json" as the second parameter (optional).
+// JSON output can be decoded using DecodeJSON.
 func (j *Junos) Command(cmd string, format ...string) (string, error) {
+	return j.CommandContext(context.Background(), cmd, format...)
+}
+
+// CommandContext is the same as Command, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) CommandContext(ctx context.Context, cmd string, format ...string) (string, error) {
 	var command string
 	command = fmt.Sprintf(rpcCommand, cmd)
 
@@ -338,15 +708,17 @@ func (j *Junos) Command(cmd string, format ...string) (string, error) {
 		command = fmt.Sprintf(rpcCommandXML, cmd)
 	}
 
-	reply, err := j.Session.Exec(netconf.RawMethod(command))
+	if len(format) > 0 && format[0] == "json" {
+		command = fmt.Sprintf(rpcCommandJSON, cmd)
+	}
+
+	reply, err := j.exec(ctx, command)
 	if err != nil {
 		return "", err
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return "", errors.New(m.Message)
-		}
+	if err := j.replyError(reply); err != nil {
+		return "", err
 	}
 
 	if reply.Data == "" {
@@ -363,21 +735,29 @@ func (j *Junos) Command(cmd string, format ...string) (string, error) {
 		return output.Config, nil
 	}
 
+	if len(format) > 0 && format[0] == "json" {
+		return jsonReply(reply.Data)
+	}
+
 	return reply.Data, nil
 }
 
 // CommitHistory gathers all the information about the previous 5 commits.
 func (j *Junos) CommitHistory() (*CommitHistory, error) {
+	return j.CommitHistoryContext(context.Background())
+}
+
+// CommitHistoryContext is the same as CommitHistory, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) CommitHistoryContext(ctx context.Context) (*CommitHistory, error) {
 	var history CommitHistory
-	reply, err := j.Session.Exec(netconf.RawMethod(rpcCommitHistory))
+	reply, err := j.exec(ctx, rpcCommitHistory)
 	if err != nil {
 		return nil, err
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return nil, errors.New(m.Message)
-		}
+	if err := j.replyError(reply); err != nil {
+		return nil, err
 	}
 
 	if reply.Data == "" {
@@ -395,131 +775,110 @@ func (j *Junos) CommitHistory() (*CommitHistory, error) {
 
 // Commit commits the configuration.
 func (j *Junos) Commit() error {
-	var errs commitResults
-	reply, err := j.Session.Exec(netconf.RawMethod(rpcCommit))
-	if err != nil {
+	return j.CommitContext(context.Background())
+}
+
+// CommitContext is the same as Commit, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) CommitContext(ctx context.Context) error {
+	if _, err := j.CommitWithResult(ctx); err != nil {
 		return err
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
-		}
-	}
+	return j.commitDelay(ctx)
+}
 
-	formatted := strings.Replace(reply.Data, "\n", "", -1)
-	err = xml.Unmarshal([]byte(formatted), &errs)
-	if err != nil {
-		return err
+// CommitWithResult is the same as CommitContext, but also returns the warnings the device
+// reported, which don't fail the commit unless WarningPolicy is FatalWarnings.
+func (j *Junos) CommitWithResult(ctx context.Context) (*CommitResult, error) {
+	return j.CommitWithOptions(ctx, nil)
+}
+
+// commit runs a commit-configuration RPC, and checks the results for errors.
+func (j *Junos) commit(ctx context.Context, command string) (*CommitResult, error) {
+	if err := j.require(CapabilityCandidate); err != nil {
+		return nil, err
 	}
 
-	if errs.Errors != nil {
-		for _, m := range errs.Errors {
-			return errors.New(strings.Trim(m.Message, "[\r\n]"))
+	var warnings []*RPCError
+	err := j.retry(ctx, func() error {
+		reply, err := j.exec(ctx, command)
+		if err != nil {
+			return err
+		}
+
+		var results commitReply
+		if err := xml.Unmarshal([]byte("<reply>"+reply.Data+"</reply>"), &results); err != nil {
+			return err
 		}
-	}
 
-	if j.CommitTimeout > 0 {
-		time.Sleep(j.CommitTimeout * time.Second)
+		warnings, err = j.checkErrors(replyErrors(reply), results.Results, results.REResults)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	return &CommitResult{Warnings: warnings}, nil
 }
 
 // CommitAt commits the configuration at the specified time. Time must be in 24-hour HH:mm format.
 // Specifying a commit message is optional.
 func (j *Junos) CommitAt(time string, message ...string) error {
-	var errs commitResults
-	command := fmt.Sprintf(rpcCommitAt, time)
+	return j.CommitAtContext(context.Background(), time, message...)
+}
 
+// CommitAtContext is the same as CommitAt, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) CommitAtContext(ctx context.Context, time string, message ...string) error {
+	opts := &CommitOptions{At: time}
 	if len(message) > 0 {
-		command = fmt.Sprintf(rpcCommitAtLog, time, message[0])
-	}
-
-	reply, err := j.Session.Exec(netconf.RawMethod(command))
-	if err != nil {
-		return err
+		opts.Comment = message[0]
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
-		}
-	}
+	_, err := j.CommitWithOptions(ctx, opts)
 
-	formatted := strings.Replace(reply.Data, "\n", "", -1)
-	err = xml.Unmarshal([]byte(formatted), &errs)
-	if err != nil {
-		return err
-	}
-
-	if errs.Errors != nil {
-		for _, m := range errs.Errors {
-			return errors.New(strings.Trim(m.Message, "[\r\n]"))
-		}
-	}
-
-	return nil
+	return err
 }
 
 // CommitCheck checks the configuration for syntax errors, but does not commit any changes.
 func (j *Junos) CommitCheck() error {
-	var errs commitResults
-	reply, err := j.Session.Exec(netconf.RawMethod(rpcCommitCheck))
-	if err != nil {
-		return err
-	}
-
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
-		}
-	}
+	return j.CommitCheckContext(context.Background())
+}
 
-	formatted := strings.Replace(reply.Data, "\n", "", -1)
-	err = xml.Unmarshal([]byte(formatted), &errs)
-	if err != nil {
-		return err
-	}
+// CommitCheckContext is the same as CommitCheck, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) CommitCheckContext(ctx context.Context) error {
+	_, err := j.CommitCheckWithResult(ctx)
 
-	if errs.Errors != nil {
-		for _, m := range errs.Errors {
-			return errors.New(strings.Trim(m.Message, "[\r\n]"))
-		}
-	}
+	return err
+}
 
-	return nil
+// CommitCheckWithResult is the same as CommitCheckContext, but also returns the warnings the
+// device reported, which don't fail the check unless WarningPolicy is FatalWarnings.
+func (j *Junos) CommitCheckWithResult(ctx context.Context) (*CommitResult, error) {
+	return j.CommitWithOptions(ctx, &CommitOptions{Check: true})
 }
 
-// CommitConfirm rolls back the configuration after the delayed minutes.
+// CommitConfirm rolls back the configuration after the delayed minutes, unless it's
+// confirmed using ConfirmCommit.
 func (j *Junos) CommitConfirm(delay int) error {
-	var errs commitResults
-	command := fmt.Sprintf(rpcCommitConfirm, delay)
-	reply, err := j.Session.Exec(netconf.RawMethod(command))
-	if err != nil {
-		return err
-	}
-
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
-		}
-	}
+	return j.CommitConfirmContext(context.Background(), delay)
+}
 
-	formatted := strings.Replace(reply.Data, "\n", "", -1)
-	err = xml.Unmarshal([]byte(formatted), &errs)
-	if err != nil {
-		return err
-	}
+// CommitConfirmContext is the same as CommitConfirm, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) CommitConfirmContext(ctx context.Context, delay int) error {
+	_, err := j.CommitConfirmWithResult(ctx, delay)
 
-	if errs.Errors != nil {
-		for _, m := range errs.Errors {
-			message := fmt.Sprintf("[%s]\n    %s\nError: %s", strings.Trim(m.Path, "[\r\n]"), strings.Trim(m.Element, "[\r\n]"), strings.Trim(m.Message, "[\r\n]"))
-			return errors.New(message)
-		}
-	}
+	return err
+}
 
-	return nil
+// CommitConfirmWithResult is the same as CommitConfirmContext, but also returns the warnings
+// the device reported, which don't fail the commit unless WarningPolicy is FatalWarnings.
+func (j *Junos) CommitConfirmWithResult(ctx context.Context, delay int) (*CommitResult, error) {
+	return j.CommitWithOptions(ctx, &CommitOptions{Confirmed: delay})
 }
 
 // Diff compares candidate config to current (rollback 0) or previous rollback
@@ -528,17 +887,21 @@ func (j *Junos) CommitConfirm(delay int) error {
 // RPC: <get-configuration compare="rollback" rollback="[0-49]" format="text"/>
 // https://goo.gl/wFRMX9 (juniper.net)
 func (j *Junos) Diff(rollback int) (string, error) {
+	return j.DiffContext(context.Background(), rollback)
+}
+
+// DiffContext is the same as Diff, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) DiffContext(ctx context.Context, rollback int) (string, error) {
 	var cd cdiffXML
 	command := fmt.Sprintf(rpcGetCandidateCompare, rollback)
-	reply, err := j.Session.Exec(netconf.RawMethod(command))
+	reply, err := j.exec(ctx, command)
 	if err != nil {
 		return "", err
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return "", errors.New(m.Message)
-		}
+	if err := j.replyError(reply); err != nil {
+		return "", err
 	}
 
 	// formatted := strings.Replace(reply.Data, "\n", "", -1)
@@ -547,19 +910,24 @@ func (j *Junos) Diff(rollback int) (string, error) {
 		return "", err
 	}
 
-	if cd.Error != "" {
-		errMessage := strings.Trim(cd.Error, "\r\n")
-		return "", errors.New(errMessage)
+	if _, err := j.checkErrors(cd.Errors); err != nil {
+		return "", err
 	}
 
 	return cd.Config, nil
 }
 
 // GetConfig returns the configuration starting at the given section. If you do not specify anything
-// for section, then the entire configuration will be returned. Format must be "text" or "xml." You
+// for section, then the entire configuration will be returned. Format must be "text", "xml" or "json." You
 // can do sub-sections by separating the section path with a ">" symbol, i.e. "system>login" or "protocols>ospf>area."
 // The default option is to return the XML.
 func (j *Junos) GetConfig(format string, section ...string) (string, error) {
+	return j.GetConfigContext(context.Background(), format, section...)
+}
+
+// GetConfigContext is the same as GetConfig, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) GetConfigContext(ctx context.Context, format string, section ...string) (string, error) {
 	command := fmt.Sprintf("<get-configuration format=\"%s\"><configuration>", format)
 
 	if len(section) > 0 {
@@ -583,7 +951,7 @@ func (j *Junos) GetConfig(format string, section ...string) (string, error) {
 		command += "</configuration></get-configuration>"
 	}
 
-	reply, err := j.Session.Exec(netconf.RawMethod(command))
+	reply, err := j.exec(ctx, command)
 	if err != nil {
 		return "", err
 	}
@@ -592,10 +960,8 @@ func (j *Junos) GetConfig(format string, section ...string) (string, error) {
 		return "", errors.New("the section you provided is not configured on the device")
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return "", errors.New(m.Message)
-		}
+	if err := j.replyError(reply); err != nil {
+		return "", err
 	}
 
 	switch format {
@@ -614,6 +980,8 @@ func (j *Junos) GetConfig(format string, section ...string) (string, error) {
 		return output.Config, nil
 	case "xml":
 		return reply.Data, nil
+	case "json":
+		return jsonReply(reply.Data)
 	}
 
 	return reply.Data, nil
@@ -622,115 +990,100 @@ func (j *Junos) GetConfig(format string, section ...string) (string, error) {
 // Config loads a given configuration file from your local machine,
 // a remote (FTP or HTTP server) location, or via configuration statements
 // from variables (type string or []string) within your script. Format must be
-// "set", "text" or "xml".
+// "set", "text", "xml" or "json". The set format is loaded using the set action, and the
+// others using the replace action. Use Load to choose the action (e.g. override).
 func (j *Junos) Config(path interface{}, format string, commit bool) error {
-	var command string
-	switch format {
-	case "set":
-		switch path.(type) {
-		case string:
-			if strings.Contains(path.(string), "tp://") {
-				command = fmt.Sprintf(rpcConfigURLSet, path.(string))
-			}
-
-			if _, err := ioutil.ReadFile(path.(string)); err != nil {
-				command = fmt.Sprintf(rpcConfigStringSet, path.(string))
-			} else {
-				data, err := ioutil.ReadFile(path.(string))
-				if err != nil {
-					return err
-				}
-
-				command = fmt.Sprintf(rpcConfigFileSet, string(data))
-			}
-		case []string:
-			command = fmt.Sprintf(rpcConfigStringSet, strings.Join(path.([]string), "\n"))
-		}
-	case "text":
-		switch path.(type) {
-		case string:
-			if strings.Contains(path.(string), "tp://") {
-				command = fmt.Sprintf(rpcConfigURLText, path.(string))
-			}
+	return j.ConfigContext(context.Background(), path, format, commit)
+}
 
-			if _, err := ioutil.ReadFile(path.(string)); err != nil {
-				command = fmt.Sprintf(rpcConfigStringText, path.(string))
-			} else {
-				data, err := ioutil.ReadFile(path.(string))
-				if err != nil {
-					return err
-				}
+// ConfigContext is the same as Config, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) ConfigContext(ctx context.Context, path interface{}, format string, commit bool) error {
+	_, err := j.ConfigWithResult(ctx, path, format, commit)
 
-				command = fmt.Sprintf(rpcConfigFileText, string(data))
-			}
-		case []string:
-			command = fmt.Sprintf(rpcConfigStringText, strings.Join(path.([]string), "\n"))
-		}
-	case "xml":
-		switch path.(type) {
-		case string:
-			if strings.Contains(path.(string), "tp://") {
-				command = fmt.Sprintf(rpcConfigURLXML, path.(string))
-			}
-
-			if _, err := ioutil.ReadFile(path.(string)); err != nil {
-				command = fmt.Sprintf(rpcConfigStringXML, path.(string))
-			} else {
-				data, err := ioutil.ReadFile(path.(string))
-				if err != nil {
-					return err
-				}
+	return err
+}
 
-				command = fmt.Sprintf(rpcConfigFileXML, string(data))
-			}
-		case []string:
-			command = fmt.Sprintf(rpcConfigStringXML, strings.Join(path.([]string), "\n"))
-		}
+// ConfigWithResult is the same as ConfigContext, but also returns the warnings the device
+// reported, which don't fail the load (or commit) unless WarningPolicy is FatalWarnings.
+func (j *Junos) ConfigWithResult(ctx context.Context, path interface{}, format string, commit bool) (*LoadResult, error) {
+	opts, err := configOptions(path, format)
+	if err != nil {
+		return nil, err
 	}
 
-	reply, err := j.Session.Exec(netconf.RawMethod(command))
+	res, err := j.Load(ctx, opts)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if commit {
-		err = j.Commit()
+		res.Commit, err = j.CommitWithResult(ctx)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
+	return res, nil
+}
+
+// load runs a load-configuration RPC, and checks the results for errors.
+func (j *Junos) load(ctx context.Context, command string) ([]*RPCError, error) {
+	var warnings []*RPCError
+	err := j.retry(ctx, func() error {
+		reply, err := j.exec(ctx, command)
+		if err != nil {
+			return err
 		}
-	}
 
-	return nil
+		var results loadReply
+		if err := xml.Unmarshal([]byte("<reply>"+reply.Data+"</reply>"), &results); err != nil {
+			return err
+		}
+
+		warnings, err = j.checkErrors(replyErrors(reply), results.Results)
+
+		return err
+	})
+
+	return warnings, err
 }
 
 // Lock locks the candidate configuration.
 func (j *Junos) Lock() error {
-	reply, err := j.Session.Exec(netconf.RawMethod(rpcLock))
-	if err != nil {
+	return j.LockContext(context.Background())
+}
+
+// LockContext is the same as Lock, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) LockContext(ctx context.Context) error {
+	if err := j.require(CapabilityCandidate); err != nil {
 		return err
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
+	err := j.retry(ctx, func() error {
+		reply, err := j.exec(ctx, rpcLock)
+		if err != nil {
+			return err
 		}
-	}
 
-	if j.CommitTimeout > 0 {
-		time.Sleep(j.CommitTimeout * time.Second)
+		return j.replyError(reply)
+	})
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return j.commitDelay(ctx)
 }
 
 // Rescue will create or delete the rescue configuration given "save" or "delete" for the action.
 func (j *Junos) Rescue(action string) error {
+	return j.RescueContext(context.Background(), action)
+}
+
+// RescueContext is the same as Rescue, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) RescueContext(ctx context.Context, action string) error {
 	var command string
 
 	switch action {
@@ -742,15 +1095,13 @@ func (j *Junos) Rescue(action string) error {
 		return errors.New("you must specify save or delete for a rescue config action")
 	}
 
-	reply, err := j.Session.Exec(netconf.RawMethod(command))
+	reply, err := j.exec(ctx, command)
 	if err != nil {
 		return err
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
-		}
+	if err := j.replyError(reply); err != nil {
+		return err
 	}
 
 	return nil
@@ -758,62 +1109,71 @@ func (j *Junos) Rescue(action string) error {
 
 // Rollback loads and commits the configuration of a given rollback number or rescue state, by specifying "rescue."
 func (j *Junos) Rollback(option interface{}) error {
+	return j.RollbackContext(context.Background(), option)
+}
+
+// RollbackContext is the same as Rollback, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) RollbackContext(ctx context.Context, option interface{}) error {
+	if err := j.require(CapabilityCandidate); err != nil {
+		return err
+	}
+
 	var command = fmt.Sprintf(rpcRollbackConfig, option)
 
 	if option == "rescue" {
 		command = fmt.Sprintf(rpcRescueConfig)
 	}
 
-	reply, err := j.Session.Exec(netconf.RawMethod(command))
-	if err != nil {
-		return err
-	}
-
-	err = j.Commit()
-	if err != nil {
+	if _, err := j.load(ctx, command); err != nil {
 		return err
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
-		}
-	}
-
-	return nil
+	return j.CommitContext(ctx)
 }
 
 // Unlock unlocks the candidate configuration.
 func (j *Junos) Unlock() error {
-	reply, err := j.Session.Exec(netconf.RawMethod(rpcUnlock))
-	if err != nil {
+	return j.UnlockContext(context.Background())
+}
+
+// UnlockContext is the same as Unlock, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) UnlockContext(ctx context.Context) error {
+	if err := j.require(CapabilityCandidate); err != nil {
 		return err
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
+	err := j.retry(ctx, func() error {
+		reply, err := j.exec(ctx, rpcUnlock)
+		if err != nil {
+			return err
 		}
-	}
 
-	if j.CommitTimeout > 0 {
-		time.Sleep(j.CommitTimeout * time.Second)
+		return j.replyError(reply)
+	})
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return j.commitDelay(ctx)
 }
 
 // Reboot will reboot the device.
 func (j *Junos) Reboot() error {
-	reply, err := j.Session.Exec(netconf.RawMethod(rpcReboot))
+	return j.RebootContext(context.Background())
+}
+
+// RebootContext is the same as Reboot, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) RebootContext(ctx context.Context) error {
+	reply, err := j.exec(ctx, rpcReboot)
 	if err != nil {
 		return err
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
-		}
+	if err := j.replyError(reply); err != nil {
+		return err
 	}
 
 	return nil
@@ -823,25 +1183,40 @@ func (j *Junos) Reboot() error {
 // check and evaluate the new configuration. Useful for when you get an error with
 // a commit or when you've changed the configuration significantly.
 func (j *Junos) CommitFull() error {
-	reply, err := j.Session.Exec(netconf.RawMethod(rpcCommitFull))
-	if err != nil {
-		return err
-	}
+	return j.CommitFullContext(context.Background())
+}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return errors.New(m.Message)
-		}
-	}
+// CommitFullContext is the same as CommitFull, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) CommitFullContext(ctx context.Context) error {
+	_, err := j.CommitWithOptions(ctx, &CommitOptions{Full: true})
 
-	return nil
+	return err
 }
 
 // SetCommitTimeout will add the given delay time (in seconds) to the following commit functions: Lock(),
 // Commit() and Unlock(). When configuring multiple devices, or having a large configuration to push, this can
-// greatly reduce errors (especially if you're dealing with latency).
+// greatly reduce errors (especially if you're dealing with latency). Other RPCs aren't delayed; to space
+// every RPC on the session, set RateLimit instead.
 func (j *Junos) SetCommitTimeout(delay int) {
 	d := time.Duration(delay)
 
 	j.CommitTimeout = d
 }
+
+// commitDelay waits for the delay set by SetCommitTimeout, or until ctx is done.
+func (j *Junos) commitDelay(ctx context.Context) error {
+	if j.CommitTimeout <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(j.CommitTimeout * time.Second)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
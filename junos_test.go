@@ -0,0 +1,177 @@
+package junos_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+// newServer returns a new test server, which is closed when the test finishes.
+func newServer(t *testing.T) *junostest.Server {
+	t.Helper()
+
+	srv := junostest.NewServer()
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// newSession returns a session connected to a new test server.
+func newSession(t *testing.T) (*junos.Junos, *junostest.Server) {
+	t.Helper()
+
+	srv := newServer(t)
+
+	j, err := junos.NewSession(srv.Addr, srv.Auth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(j.Close)
+
+	return j, srv
+}
+
+func TestCommand(t *testing.T) {
+	j, srv := newSession(t)
+	srv.HandleCommand("show system uptime", "<system-uptime-information/>")
+
+	out, err := j.Command("show system uptime", "xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out, "system-uptime-information") {
+		t.Errorf("Command returned %q", out)
+	}
+}
+
+func TestCommandContextCancel(t *testing.T) {
+	j, srv := newSession(t)
+
+	block := make(chan struct{})
+	defer close(block)
+	srv.HandleFunc("command", func(*junostest.Request) string {
+		<-block
+		return ""
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := j.CommandContext(ctx, "show version"); err != context.DeadlineExceeded {
+		t.Fatalf("CommandContext returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("CommandContext took %s to return after its deadline", d)
+	}
+}
+
+func TestCommandContextDone(t *testing.T) {
+	j, srv := newSession(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	srv.Reset()
+	if _, err := j.CommandContext(ctx, "show version"); err != context.Canceled {
+		t.Fatalf("CommandContext returned %v, want %v", err, context.Canceled)
+	}
+
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("%d RPCs were sent with a cancelled context", n)
+	}
+}
+
+func TestView(t *testing.T) {
+	j, _ := newSession(t)
+
+	v, err := j.View("arp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Arp.Count != 1 || len(v.Arp.Entries) != 1 || v.Arp.Entries[0].IPAddress != "192.0.2.254" {
+		t.Errorf("got ARP table %+v", v.Arp)
+	}
+
+	v, err = j.View("route")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(v.Route.RouteTables) != 1 || len(v.Route.RouteTables[0].Entries) != 2 {
+		t.Errorf("got routing table %+v", v.Route)
+	}
+}
+
+func TestGetConfig(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Reset()
+
+	config, err := j.GetConfig("text", "system")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(config, "host-name fw1;") {
+		t.Errorf("got configuration %q", config)
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 || reqs[0].Attrs["format"] != "text" || !strings.Contains(reqs[0].XML, "<system") {
+		t.Errorf("got requests %+v", reqs)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	j, _ := newSession(t)
+
+	diff, err := j.Diff(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(diff, "+  host-name fw2;") {
+		t.Errorf("got diff %q", diff)
+	}
+}
+
+func TestLock(t *testing.T) {
+	j, srv := newSession(t)
+
+	if err := j.Lock(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := j.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Handle("lock-configuration", junostest.LockError())
+	if err := j.Lock(); err == nil || !strings.Contains(err.Error(), "configuration database locked") {
+		t.Errorf("Lock on a locked configuration returned %v", err)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	j, srv := newSession(t)
+
+	if err := j.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Handle("commit-configuration", junostest.CommitError("[edit security policies]", "policy", "missing mandatory statement: 'match'"))
+	if err := j.Commit(); err == nil || !strings.Contains(err.Error(), "missing mandatory statement") {
+		t.Errorf("a failed commit returned %v", err)
+	}
+
+	if n := srv.Received("commit-configuration"); n != 2 {
+		t.Errorf("the server received %d commits, want 2", n)
+	}
+}
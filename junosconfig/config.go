@@ -0,0 +1,196 @@
+// Package junosconfig parses Junos configurations in the curly-brace text format (i.e. the
+// output of "show configuration", or GetConfig("text")) into a tree, without needing a
+// connection to a device. The tree can be walked, queried by path, and written back out as
+// text, set commands or XML.
+//
+//	config, err := junosconfig.ParseFile("fw1.conf")
+//	if err != nil {
+//		fmt.Println(err)
+//	}
+//
+//	if n := config.Get("system host-name"); n != nil {
+//		fmt.Println(n.Value())
+//	}
+//
+//	fmt.Print(config.Set())
+package junosconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+)
+
+// Node is a statement in a configuration. The root of the tree returned by Parse is a node
+// without any words, whose children are the top-level statements.
+//
+// Words are the words of the statement, unquoted, e.g. []string{"host-name", "fw1"} or
+// []string{"unit", "0"}. Values are the values of a statement with a list of them, e.g. a
+// and b in "members [ a b ];". Children are the statements in its block, if it has one.
+//
+// Inactive, Protect and Replace are set when the statement is tagged with inactive:,
+// protect: or replace:. Annotation is the text of the /* */ comment before the statement.
+type Node struct {
+	Words      []string
+	Values     []string
+	Children   []*Node
+	Inactive   bool
+	Protect    bool
+	Replace    bool
+	Annotation string
+
+	parent *Node
+}
+
+// Parse parses a configuration in text format, and returns the root of the tree.
+func Parse(text string) (*Node, error) {
+	p := &parser{lex: newLexer(text)}
+
+	root := &Node{}
+	if err := p.statements(root, false); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+// ParseFile parses a configuration in text format from a file, e.g. a saved backup.
+func ParseFile(path string) (*Node, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s - %s", path, err)
+	}
+
+	return Parse(string(data))
+}
+
+// Parent returns the node's parent, or nil for the root.
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+// Name returns the first word of the statement, e.g. "host-name".
+func (n *Node) Name() string {
+	if len(n.Words) == 0 {
+		return ""
+	}
+
+	return n.Words[0]
+}
+
+// Value returns the last word of the statement, e.g. "fw1" for "host-name fw1", or an empty
+// string for a statement with only one word.
+func (n *Node) Value() string {
+	if len(n.Words) < 2 {
+		return ""
+	}
+
+	return n.Words[len(n.Words)-1]
+}
+
+// Path returns the words of the statement and the statements it's in, e.g.
+// []string{"interfaces", "ge-0/0/0", "unit", "0"}.
+func (n *Node) Path() []string {
+	var path []string
+	for p := n; p != nil; p = p.parent {
+		path = append(append([]string{}, p.Words...), path...)
+	}
+
+	return path
+}
+
+// Walk calls fn for the node and every statement beneath it, in order. If fn returns false,
+// the statements beneath that node are skipped.
+func (n *Node) Walk(fn func(n *Node) bool) {
+	if !fn(n) {
+		return
+	}
+
+	for _, c := range n.Children {
+		c.Walk(fn)
+	}
+}
+
+// Find returns the statements beneath the node matching the path, which is the words of the
+// statements separated by spaces, e.g. "interfaces ge-0/0/0 unit 0". A path can stop part
+// way through a statement (e.g. "system host-name" finds "host-name fw1"), name a value in a
+// list (e.g. "vlans v10 members a"), and use * to match any word, e.g. "interfaces * unit *".
+func (n *Node) Find(path string) []*Node {
+	words, err := pathWords(path)
+	if err != nil || len(words) == 0 {
+		return nil
+	}
+
+	var found []*Node
+	n.find(words, &found)
+
+	return found
+}
+
+// Get returns the first statement matching the path (see Find), or nil if there isn't one.
+func (n *Node) Get(path string) *Node {
+	found := n.Find(path)
+	if len(found) == 0 {
+		return nil
+	}
+
+	return found[0]
+}
+
+// find adds the children matching the path to found.
+func (n *Node) find(path []string, found *[]*Node) {
+	for _, c := range n.Children {
+		if len(path) <= len(c.Words) {
+			if matchWords(path, c.Words[:len(path)]) {
+				*found = append(*found, c)
+			}
+
+			continue
+		}
+
+		if !matchWords(path[:len(c.Words)], c.Words) {
+			continue
+		}
+
+		rest := path[len(c.Words):]
+		if len(rest) == 1 {
+			for _, v := range c.Values {
+				if matchWords(rest, []string{v}) {
+					*found = append(*found, c)
+					break
+				}
+			}
+		}
+
+		c.find(rest, found)
+	}
+}
+
+// matchWords returns whether the words match the pattern, where * matches any word.
+func matchWords(pattern, words []string) bool {
+	for i, p := range pattern {
+		if p != "*" && p != words[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pathWords splits a path into its words, unquoting any quoted words.
+func pathWords(path string) ([]string, error) {
+	lex := newLexer(path)
+
+	var words []string
+	for {
+		tok, err := lex.next()
+		if err != nil {
+			return nil, err
+		}
+
+		if tok.kind == tokenEOF {
+			return words, nil
+		}
+
+		words = append(words, tok.text)
+	}
+}
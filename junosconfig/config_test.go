@@ -0,0 +1,219 @@
+package junosconfig_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scottdware/go-junos/junosconfig"
+)
+
+// sample is a configuration in text format, with comments, an annotation, quoted strings,
+// a list of values, and statements tagged with inactive:, protect: and replace:.
+const sample = `## Last changed: 2019-10-15 12:30:01 UTC
+version 18.4R1.8;
+system {
+    host-name fw1;
+    /* the admins */
+    login {
+        user admin {
+            class super-user;
+            authentication {
+                encrypted-password "$6$abc"; ## SECRET-DATA
+            }
+        }
+    }
+    services {
+        ssh;
+    }
+}
+interfaces {
+    ge-0/0/0 {
+        description "uplink to core";
+        unit 0 {
+            family inet {
+                address 10.0.0.1/24;
+            }
+        }
+    }
+    inactive: ge-0/0/1 {
+        disable;
+    }
+}
+protect: vlans {
+    v10 {
+        vlan-id 10;
+        members [ a "b c" ];
+    }
+}
+replace: routing-options {
+    static {
+        route 0.0.0.0/0 next-hop 10.0.0.254;
+    }
+}
+`
+
+func parseSample(t *testing.T) *junosconfig.Node {
+	t.Helper()
+
+	config, err := junosconfig.Parse(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return config
+}
+
+func TestParse(t *testing.T) {
+	config := parseSample(t)
+
+	var names []string
+	for _, n := range config.Children {
+		names = append(names, n.Name())
+	}
+
+	want := []string{"version", "system", "interfaces", "vlans", "routing-options"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got top-level statements %q, want %q", names, want)
+	}
+
+	if n := config.Get("system host-name"); n == nil || n.Value() != "fw1" {
+		t.Errorf("got host-name %+v, want fw1", n)
+	}
+
+	if n := config.Get("interfaces ge-0/0/0 description"); n == nil || n.Value() != "uplink to core" {
+		t.Errorf("got description %+v, want the unquoted string", n)
+	}
+
+	if n := config.Get("system login user admin authentication encrypted-password"); n == nil || n.Value() != "$6$abc" {
+		t.Errorf("got encrypted-password %+v, want $6$abc", n)
+	}
+
+	if n := config.Get("vlans v10 members"); n == nil || !reflect.DeepEqual(n.Values, []string{"a", "b c"}) {
+		t.Errorf("got members %+v, want [a, b c]", n)
+	}
+}
+
+func TestParseTags(t *testing.T) {
+	config := parseSample(t)
+
+	if n := config.Get("system login"); n.Annotation != "the admins" {
+		t.Errorf("got annotation %q, want %q", n.Annotation, "the admins")
+	}
+
+	if !config.Get("interfaces ge-0/0/1").Inactive || config.Get("interfaces ge-0/0/0").Inactive {
+		t.Error("only ge-0/0/1 should be inactive")
+	}
+
+	if !config.Get("vlans").Protect {
+		t.Error("vlans isn't protected")
+	}
+
+	if !config.Get("routing-options").Replace {
+		t.Error("routing-options isn't tagged with replace:")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{
+		"system {",
+		"}",
+		"{",
+		`host-name "fw1`,
+		"host-name fw1",
+		"/* unterminated",
+		"members [ a b ;",
+	} {
+		if _, err := junosconfig.Parse(text); err == nil {
+			t.Errorf("Parse(%q) didn't return an error", text)
+		}
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fw1.conf")
+	if err := ioutil.WriteFile(path, []byte(sample), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := junosconfig.ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Get("system host-name").Value() != "fw1" {
+		t.Error("the file wasn't parsed")
+	}
+
+	if _, err := junosconfig.ParseFile(filepath.Join(t.TempDir(), "missing.conf")); err == nil {
+		t.Error("ParseFile didn't return an error for a missing file")
+	}
+}
+
+func TestFind(t *testing.T) {
+	config := parseSample(t)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"interfaces *", 2},
+		{"interfaces * unit *", 1},
+		{"system host-name", 1},
+		{"system host-name fw1", 1},
+		{"system host-name fw2", 0},
+		{`vlans v10 members "b c"`, 1},
+		{"vlans v10 members d", 0},
+		{"routing-options static route 0.0.0.0/0 next-hop", 1},
+		{"snmp", 0},
+		{"", 0},
+	}
+
+	for _, tt := range tests {
+		if got := len(config.Find(tt.path)); got != tt.want {
+			t.Errorf("Find(%q) returned %d statements, want %d", tt.path, got, tt.want)
+		}
+	}
+
+	if config.Get("snmp") != nil {
+		t.Error("Get returned a statement that isn't in the configuration")
+	}
+}
+
+func TestPath(t *testing.T) {
+	config := parseSample(t)
+
+	unit := config.Get("interfaces ge-0/0/0 unit 0")
+	want := []string{"interfaces", "ge-0/0/0", "unit", "0"}
+	if !reflect.DeepEqual(unit.Path(), want) {
+		t.Errorf("got path %q, want %q", unit.Path(), want)
+	}
+
+	if unit.Parent() != config.Get("interfaces ge-0/0/0") {
+		t.Error("the unit's parent isn't its interface")
+	}
+
+	if config.Parent() != nil {
+		t.Error("the root has a parent")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	config := parseSample(t)
+
+	var names []string
+	config.Walk(func(n *junosconfig.Node) bool {
+		if n.Parent() == nil {
+			return true
+		}
+
+		names = append(names, n.Name())
+		return n.Name() != "system" && n.Name() != "interfaces" && n.Name() != "vlans"
+	})
+
+	want := []string{"version", "system", "interfaces", "vlans", "routing-options", "static", "route"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("walked %q, want %q", names, want)
+	}
+}
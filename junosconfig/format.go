@@ -0,0 +1,389 @@
+package junosconfig
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// flattenedLists maps the configuration elements whose list entries don't include the name
+// of the list element in text format, e.g. "interfaces { ge-0/0/0 { ... } }", which is
+// <interfaces><interface><name>ge-0/0/0</name>...</interface></interfaces> in XML. The
+// single-word ones are only flattened at the top level (or of a group); elsewhere, e.g. in a
+// security zone, "interfaces { ge-0/0/0.0; }" is a list of <interfaces> elements.
+var flattenedLists = map[string]string{
+	"interfaces":        "interface",
+	"vlans":             "vlan",
+	"routing-instances": "instance",
+	"bridge-domains":    "domain",
+	"prefix-list":       "prefix-list-item",
+}
+
+// listStatements are the statements that can be in the block of a flattened list, but
+// aren't entries in it, e.g. "interfaces { apply-groups common; }" is
+// <interfaces><apply-groups>common</apply-groups></interfaces> in XML.
+var listStatements = map[string]bool{
+	"apply-groups":        true,
+	"apply-groups-except": true,
+	"apply-macro":         true,
+	"interface-range":     true,
+	"interface-set":       true,
+	"traceoptions":        true,
+}
+
+// unwrappedLists are the lists whose entries are in a block in text format, but aren't
+// wrapped in an element in XML, e.g. "groups { node0 { ... } }" is
+// <groups><name>node0</name>...</groups>. They're only at the top level, and the statements
+// in an entry are the same as the top-level ones.
+var unwrappedLists = map[string]bool{
+	"groups": true,
+}
+
+// keywordContainers are the elements whose next word in text format is the name of the
+// element inside them, rather than a list entry's name, e.g. "family inet { ... }" is
+// <family><inet>...</inet></family> in XML.
+var keywordContainers = map[string]bool{
+	"family": true,
+}
+
+// Text returns the node in text format, i.e. the statement and its block. For the root,
+// it's the whole configuration.
+func (n *Node) Text() string {
+	var b strings.Builder
+
+	if n.parent == nil {
+		for _, c := range n.Children {
+			c.text(&b, 0)
+		}
+	} else {
+		n.text(&b, 0)
+	}
+
+	return b.String()
+}
+
+// text writes the statement and its block, indented by depth.
+func (n *Node) text(b *strings.Builder, depth int) {
+	indent := strings.Repeat("    ", depth)
+
+	if n.Annotation != "" {
+		b.WriteString(indent + "/* " + n.Annotation + " */\n")
+	}
+
+	b.WriteString(indent)
+	if n.Replace {
+		b.WriteString("replace: ")
+	}
+	if n.Protect {
+		b.WriteString("protect: ")
+	}
+	if n.Inactive {
+		b.WriteString("inactive: ")
+	}
+	b.WriteString(quoteWords(n.Words))
+
+	switch {
+	case len(n.Children) > 0:
+		b.WriteString(" {\n")
+		for _, c := range n.Children {
+			c.text(b, depth+1)
+		}
+		b.WriteString(indent + "}\n")
+	case n.Values != nil:
+		b.WriteString(" [ " + quoteWords(n.Values) + " ];\n")
+	default:
+		b.WriteString(";\n")
+	}
+}
+
+// Set returns the configuration mode commands that create the node and the statements
+// beneath it, i.e. the same as "show configuration | display set". Annotations and
+// replace: tags aren't included, since there aren't set commands for them.
+func (n *Node) Set() string {
+	var b strings.Builder
+
+	if n.parent == nil {
+		for _, c := range n.Children {
+			c.set(&b, nil)
+		}
+	} else {
+		n.set(&b, n.parent.Path())
+	}
+
+	return b.String()
+}
+
+// set writes the commands for the statement, which is beneath the given path.
+func (n *Node) set(b *strings.Builder, path []string) {
+	path = append(append([]string{}, path...), n.Words...)
+	full := quoteWords(path)
+
+	switch {
+	case len(n.Children) > 0:
+		for _, c := range n.Children {
+			c.set(b, path)
+		}
+	case n.Values != nil:
+		for _, v := range n.Values {
+			b.WriteString("set " + full + " " + quoteWord(v) + "\n")
+		}
+	default:
+		b.WriteString("set " + full + "\n")
+	}
+
+	if n.Inactive {
+		b.WriteString("deactivate " + full + "\n")
+	}
+	if n.Protect {
+		b.WriteString("protect " + full + "\n")
+	}
+}
+
+// XML returns the node in XML format. For the root, it's the whole configuration, wrapped
+// in a <configuration> element, which can be loaded using junos.LoadOptions.
+//
+// Text format doesn't say which words are element names, and which are values, so the XML
+// is built without knowing the schema: the first word of a statement is an element, the
+// next is its name (or its value, if it's the last word of a leaf), and so on. That's right
+// for almost every statement (apart from a few, such as "family inet", which are known to be
+// nested elements), but not for lists whose entries only have a name, e.g.
+// "address 10.0.0.1/24;" is written as <address>10.0.0.1/24</address>. An error is returned
+// if a word that would be an element isn't a valid element name, e.g. a block that mixes
+// statements with list entries that aren't known, such as "ge-0/0/0".
+func (n *Node) XML() (string, error) {
+	var b bytes.Buffer
+
+	if n.parent == nil {
+		b.WriteString("<configuration xmlns:junos=\"http://xml.juniper.net/junos/*/junos\">\n")
+		for _, c := range n.Children {
+			if err := c.xml(&b, "", 1); err != nil {
+				return "", err
+			}
+		}
+		b.WriteString("</configuration>\n")
+	} else if err := n.xml(&b, elementName(n.parent.Words), 0); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// xml writes the statement as XML, indented by depth. Parent is the name of the element
+// the statement is in.
+func (n *Node) xml(b *bytes.Buffer, parent string, depth int) error {
+	indent := strings.Repeat("    ", depth)
+
+	if n.Annotation != "" {
+		b.WriteString(indent + "<junos:comment>/* ")
+		xml.EscapeText(b, []byte(n.Annotation))
+		b.WriteString(" */</junos:comment>\n")
+	}
+
+	words := n.Words
+	keyed := false
+	replace, protect, inactive := n.Replace, n.Protect, n.Inactive
+	if element, ok := n.parent.listElement(); ok && !listStatements[n.Name()] {
+		words = append([]string{element}, words...)
+		keyed = true
+	}
+	if n.parent != nil && n.parent.unwrapped() {
+		// There's no element for the list itself, so its tags go on each entry.
+		words = append([]string{parent}, words...)
+		keyed = true
+		replace, protect, inactive = replace || n.parent.Replace, protect || n.parent.Protect, inactive || n.parent.Inactive
+	}
+
+	var attrs string
+	if replace {
+		attrs += " replace=\"replace\""
+	}
+	if protect {
+		attrs += " protect=\"protect\""
+	}
+	if inactive {
+		attrs += " inactive=\"inactive\""
+	}
+
+	if n.Values != nil {
+		for _, v := range n.Values {
+			if err := n.element(b, append(append([]string{}, words...), v), keyed, attrs, indent, depth); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return n.element(b, words, keyed, attrs, indent, depth)
+}
+
+// element writes the elements for the words of a statement, followed by its children.
+func (n *Node) element(b *bytes.Buffer, words []string, keyed bool, attrs, indent string, depth int) error {
+	name := words[0]
+	if !validName(name) {
+		return fmt.Errorf("error writing %s as XML - %q isn't a valid element name", quoteWords(n.Path()), name)
+	}
+
+	switch {
+	case len(words) == 1 && n.unwrapped() && len(n.Children) > 0:
+		for _, c := range n.Children {
+			if err := c.xml(b, name, depth); err != nil {
+				return err
+			}
+		}
+	case len(words) == 1 && len(n.Children) == 0:
+		b.WriteString(indent + "<" + name + attrs + "/>\n")
+	case len(words) == 1:
+		b.WriteString(indent + "<" + name + attrs + ">\n")
+		for _, c := range n.Children {
+			if err := c.xml(b, name, depth+1); err != nil {
+				return err
+			}
+		}
+		b.WriteString(indent + "</" + name + ">\n")
+	case len(words) > 1 && keywordContainers[name]:
+		b.WriteString(indent + "<" + name + attrs + ">\n")
+		if err := n.element(b, words[1:], false, "", indent+"    ", depth+1); err != nil {
+			return err
+		}
+		b.WriteString(indent + "</" + name + ">\n")
+	case len(words) == 2 && len(n.Children) == 0 && !keyed:
+		b.WriteString(indent + "<" + name + attrs + ">")
+		xml.EscapeText(b, []byte(words[1]))
+		b.WriteString("</" + name + ">\n")
+	default:
+		b.WriteString(indent + "<" + name + attrs + ">\n")
+		b.WriteString(indent + "    <name>")
+		xml.EscapeText(b, []byte(words[1]))
+		b.WriteString("</name>\n")
+
+		if len(words) > 2 {
+			if err := n.element(b, words[2:], false, "", indent+"    ", depth+1); err != nil {
+				return err
+			}
+		} else {
+			parent := name
+			if keyed && unwrappedLists[name] && n.parent.unwrapped() {
+				parent = ""
+			}
+
+			for _, c := range n.Children {
+				if err := c.xml(b, parent, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+
+		b.WriteString(indent + "</" + name + ">\n")
+	}
+
+	return nil
+}
+
+// validName returns whether a word can be an element name. Junos' element names are made up
+// of lowercase letters, digits and hyphens, and start with a letter, but any name that's
+// valid XML (without a namespace) is allowed.
+func validName(w string) bool {
+	for i, c := range w {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case i > 0 && (c >= '0' && c <= '9' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+
+	return w != ""
+}
+
+// unwrapped returns whether the node is a list whose entries aren't wrapped in an element in
+// XML (see unwrappedLists), or a block of entries that only have a name, which is the same,
+// e.g. "name-server { 192.0.2.1; }" is <name-server><name>192.0.2.1</name></name-server>.
+func (n *Node) unwrapped() bool {
+	if len(n.Words) != 1 || len(n.Children) == 0 || n.parent == nil {
+		return false
+	}
+
+	if unwrappedLists[n.Words[0]] {
+		return n.parent.parent == nil
+	}
+
+	if _, ok := n.listElement(); ok {
+		return false
+	}
+
+	for _, c := range n.Children {
+		if validName(c.Name()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// listElement returns the element the entries in the node's block are in, if it's a flattened
+// list (see flattenedLists).
+func (n *Node) listElement() (string, bool) {
+	element, ok := flattenedLists[elementName(n.Words)]
+	if !ok || (len(n.Words) == 1 && !n.topLevel()) {
+		return "", false
+	}
+
+	return element, true
+}
+
+// topLevel returns whether the statement is at the top level of the configuration, or of a
+// group (see unwrappedLists).
+func (n *Node) topLevel() bool {
+	p := n.parent
+	if p == nil {
+		return false
+	}
+
+	if p.parent == nil {
+		return true
+	}
+
+	// Otherwise, p has to be a group, i.e. an entry in a top-level unwrapped list.
+	g := p.parent
+	return len(g.Words) == 1 && unwrappedLists[g.Words[0]] && g.parent != nil && g.parent.parent == nil
+}
+
+// elementName returns the name of the innermost element for the words of a statement (see
+// XML), which is the element its children are in.
+func elementName(words []string) string {
+	for len(words) > 2 || (len(words) == 2 && keywordContainers[words[0]]) {
+		if keywordContainers[words[0]] {
+			words = words[1:]
+		} else {
+			words = words[2:]
+		}
+	}
+
+	if len(words) == 0 {
+		return ""
+	}
+
+	return words[0]
+}
+
+// quoteWords joins words into a statement, quoting them as needed.
+func quoteWords(words []string) string {
+	quoted := make([]string, len(words))
+	for i, w := range words {
+		quoted[i] = quoteWord(w)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// quoteWord quotes a word if it's empty, or contains spaces or special characters (including
+// $, which Junos quotes, e.g. in encrypted passwords).
+func quoteWord(w string) string {
+	if w != "" && !strings.ContainsAny(w, " \t\r\n;{}[]\"#\\$") && !strings.HasPrefix(w, "/*") {
+		return w
+	}
+
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(w) + "\""
+}
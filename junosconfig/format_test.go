@@ -0,0 +1,407 @@
+package junosconfig_test
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junosconfig"
+)
+
+// toXML returns the node in XML format, and fails the test if it can't be.
+func toXML(t *testing.T, n *junosconfig.Node) string {
+	t.Helper()
+
+	s, err := n.XML()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestText(t *testing.T) {
+	config := parseSample(t)
+
+	text := config.Text()
+	for _, want := range []string{
+		"/* the admins */\n",
+		"encrypted-password \"$6$abc\";\n",
+		"inactive: ge-0/0/1 {\n",
+		"protect: vlans {\n",
+		"replace: routing-options {\n",
+		"members [ a \"b c\" ];\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("the text doesn't contain %q:\n%s", want, text)
+		}
+	}
+
+	if strings.Contains(text, "Last changed") || strings.Contains(text, "SECRET-DATA") {
+		t.Errorf("the text contains the # comments:\n%s", text)
+	}
+
+	again, err := junosconfig.Parse(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if again.Text() != text {
+		t.Errorf("the text changed when it was parsed again:\n%s", again.Text())
+	}
+
+	if got := config.Get("interfaces ge-0/0/1").Text(); got != "inactive: ge-0/0/1 {\n    disable;\n}\n" {
+		t.Errorf("got %q for ge-0/0/1", got)
+	}
+}
+
+func TestSet(t *testing.T) {
+	config := parseSample(t)
+
+	want := `set version 18.4R1.8
+set system host-name fw1
+set system login user admin class super-user
+set system login user admin authentication encrypted-password "$6$abc"
+set system services ssh
+set interfaces ge-0/0/0 description "uplink to core"
+set interfaces ge-0/0/0 unit 0 family inet address 10.0.0.1/24
+set interfaces ge-0/0/1 disable
+deactivate interfaces ge-0/0/1
+set vlans v10 vlan-id 10
+set vlans v10 members a
+set vlans v10 members "b c"
+protect vlans
+set routing-options static route 0.0.0.0/0 next-hop 10.0.0.254
+`
+	if got := config.Set(); got != want {
+		t.Errorf("got set commands:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := config.Get("interfaces ge-0/0/0 unit 0").Set(); got != "set interfaces ge-0/0/0 unit 0 family inet address 10.0.0.1/24\n" {
+		t.Errorf("got %q for unit 0", got)
+	}
+}
+
+func TestXML(t *testing.T) {
+	config := parseSample(t)
+
+	// Without the indentation, so the elements each entry starts with can be checked.
+	var lines []string
+	for _, line := range strings.Split(toXML(t, config), "\n") {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	x := strings.Join(lines, "\n")
+
+	for _, want := range []string{
+		"<host-name>fw1</host-name>",
+		"<junos:comment>/* the admins */</junos:comment>",
+		"<user>\n<name>admin</name>",
+		"<interface>\n<name>ge-0/0/0</name>",
+		"<interface inactive=\"inactive\">\n<name>ge-0/0/1</name>",
+		"<family>\n<inet>",
+		"<vlans protect=\"protect\">\n<vlan>\n<name>v10</name>",
+		"<members>b c</members>",
+		"<routing-options replace=\"replace\">",
+		"<ssh/>",
+	} {
+		if !strings.Contains(x, want) {
+			t.Errorf("the XML doesn't contain %q:\n%s", want, x)
+		}
+	}
+}
+
+func TestXMLGroups(t *testing.T) {
+	config, err := junosconfig.Parse(`groups {
+    node0 {
+        system {
+            host-name fw1-a;
+        }
+        interfaces {
+            fxp0 {
+                unit 0;
+            }
+        }
+    }
+}
+apply-groups node0;
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<configuration xmlns:junos="http://xml.juniper.net/junos/*/junos">
+    <groups>
+        <name>node0</name>
+        <system>
+            <host-name>fw1-a</host-name>
+        </system>
+        <interfaces>
+            <interface>
+                <name>fxp0</name>
+                <unit>0</unit>
+            </interface>
+        </interfaces>
+    </groups>
+    <apply-groups>node0</apply-groups>
+</configuration>
+`
+	if got := toXML(t, config); got != want {
+		t.Errorf("got XML:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := toXML(t, config.Get("groups node0 system")); got != "<system>\n    <host-name>fw1-a</host-name>\n</system>\n" {
+		t.Errorf("got %q for the group's system", got)
+	}
+}
+
+func TestXMLListStatements(t *testing.T) {
+	config, err := junosconfig.Parse(`interfaces {
+    apply-groups common;
+    interface-range r1 {
+        member ge-0/0/2;
+    }
+    ge-0/0/2 {
+        disable;
+    }
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<configuration xmlns:junos="http://xml.juniper.net/junos/*/junos">
+    <interfaces>
+        <apply-groups>common</apply-groups>
+        <interface-range>
+            <name>r1</name>
+            <member>ge-0/0/2</member>
+        </interface-range>
+        <interface>
+            <name>ge-0/0/2</name>
+            <disable/>
+        </interface>
+    </interfaces>
+</configuration>
+`
+	if got := toXML(t, config); got != want {
+		t.Errorf("got XML:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// TestXMLDiff checks the XML against the set commands, by diffing it against an empty
+// configuration, which adds every statement.
+func TestXMLDiff(t *testing.T) {
+	config, err := junosconfig.Parse(`system {
+    host-name fw1;
+    /* ssh only */
+    services {
+        ssh;
+    }
+}
+groups {
+    node0 {
+        system {
+            host-name fw1-a;
+        }
+    }
+}
+interfaces {
+    apply-groups common;
+    ge-0/0/0 {
+        unit 0 {
+            family inet {
+                dhcp;
+            }
+        }
+    }
+}
+vlans {
+    v10 {
+        members [ a b ];
+    }
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x := toXML(t, config)
+
+	same, err := junos.DiffXML(x, x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(same.Entries) != 0 {
+		t.Errorf("the XML differs from itself:\n%s", same.Set())
+	}
+
+	d, err := junos.DiffXML("", x)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Set() != config.Set() {
+		t.Errorf("the XML has the set commands:\n%s\nwant:\n%s", d.Set(), config.Set())
+	}
+}
+
+// backup is a realistic saved configuration, including lists whose entries only have a name,
+// and a list that's known to leave out its entries' element.
+const backup = `## Last changed: 2019-10-15 12:30:01 UTC
+version 18.4R1.8;
+groups {
+    node0 {
+        system {
+            host-name fw1-a;
+        }
+    }
+}
+apply-groups "${node}";
+system {
+    host-name fw1;
+    domain-name example.net;
+    time-zone America/Los_Angeles;
+    name-server {
+        192.0.2.53;
+        198.51.100.53;
+    }
+    services {
+        ssh {
+            root-login deny;
+        }
+        netconf {
+            ssh;
+        }
+    }
+    syslog {
+        file messages {
+            any notice;
+        }
+    }
+    ntp {
+        server 192.0.2.123;
+    }
+}
+snmp {
+    community public {
+        authorization read-only;
+        clients {
+            10.0.0.0/8;
+        }
+    }
+}
+interfaces {
+    ge-0/0/0 {
+        unit 0 {
+            family inet {
+                address 10.0.0.1/24;
+            }
+        }
+    }
+}
+policy-options {
+    prefix-list mgmt {
+        10.0.0.0/8;
+        192.168.0.0/16;
+    }
+}
+security {
+    zones {
+        security-zone trust {
+            host-inbound-traffic {
+                system-services {
+                    ssh;
+                }
+            }
+            interfaces {
+                ge-0/0/0.0;
+            }
+        }
+    }
+}
+firewall {
+    family inet {
+        filter protect-re {
+            term ssh {
+                from {
+                    source-prefix-list {
+                        mgmt;
+                    }
+                    protocol tcp;
+                    port ssh;
+                }
+                then accept;
+            }
+        }
+    }
+}
+routing-options {
+    static {
+        route 0.0.0.0/0 next-hop 10.0.0.254;
+    }
+}
+`
+
+func TestXMLWellFormed(t *testing.T) {
+	config, err := junosconfig.Parse(backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x := toXML(t, config)
+
+	dec := xml.NewDecoder(strings.NewReader(x))
+	for {
+		if _, err := dec.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("the XML isn't well-formed - %s:\n%s", err, x)
+		}
+	}
+
+	var lines []string
+	for _, line := range strings.Split(x, "\n") {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	x = strings.Join(lines, "\n")
+
+	for _, want := range []string{
+		"<name-server>\n<name>192.0.2.53</name>\n</name-server>\n<name-server>\n<name>198.51.100.53</name>\n</name-server>",
+		"<clients>\n<name>10.0.0.0/8</name>\n</clients>",
+		"<prefix-list-item>\n<name>192.168.0.0/16</name>\n</prefix-list-item>",
+		"<interfaces>\n<name>ge-0/0/0.0</name>\n</interfaces>",
+	} {
+		if !strings.Contains(x, want) {
+			t.Errorf("the XML doesn't contain %q:\n%s", want, x)
+		}
+	}
+
+	d, err := junos.DiffXML("", toXML(t, config))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if d.Set() != config.Set() {
+		t.Errorf("the XML has the set commands:\n%s\nwant:\n%s", d.Set(), config.Set())
+	}
+}
+
+func TestXMLInvalidName(t *testing.T) {
+	for _, text := range []string{
+		"system { host-name fw1; 192.0.2.1; }",
+		"junos:comment fw1;",
+		"system { services { ssh; ge-0/0/0 { disable; } } }",
+	} {
+		config, err := junosconfig.Parse(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if x, err := config.XML(); err == nil {
+			t.Errorf("XML of %q didn't return an error:\n%s", text, x)
+		}
+	}
+}
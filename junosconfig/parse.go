@@ -0,0 +1,236 @@
+package junosconfig
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenOpen
+	tokenClose
+	tokenSemicolon
+	tokenListOpen
+	tokenListClose
+	tokenAnnotation
+)
+
+// token is a word, punctuation or annotation in a configuration.
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+// lexer splits a configuration into tokens. Comments starting with # are skipped.
+type lexer struct {
+	s    string
+	pos  int
+	line int
+}
+
+func newLexer(s string) *lexer {
+	return &lexer{s: s, line: 1}
+}
+
+// next returns the next token.
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.s) {
+		c := l.s[l.pos]
+
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.s) && l.s[l.pos] != '\n' {
+				l.pos++
+			}
+		case strings.HasPrefix(l.s[l.pos:], "/*"):
+			return l.annotation()
+		case c == '"':
+			return l.quoted()
+		default:
+			if kind, ok := punctuation[c]; ok {
+				l.pos++
+				return token{kind: kind, text: string(c), line: l.line}, nil
+			}
+
+			start := l.pos
+			for l.pos < len(l.s) && !strings.ContainsRune(" \t\r\n{};[]\"", rune(l.s[l.pos])) {
+				l.pos++
+			}
+
+			return token{kind: tokenWord, text: l.s[start:l.pos], line: l.line}, nil
+		}
+	}
+
+	return token{kind: tokenEOF, line: l.line}, nil
+}
+
+var punctuation = map[byte]tokenKind{
+	'{': tokenOpen,
+	'}': tokenClose,
+	';': tokenSemicolon,
+	'[': tokenListOpen,
+	']': tokenListClose,
+}
+
+// annotation returns the text of a /* */ comment.
+func (l *lexer) annotation() (token, error) {
+	line := l.line
+
+	end := strings.Index(l.s[l.pos+2:], "*/")
+	if end < 0 {
+		return token{}, fmt.Errorf("syntax error on line %d - unterminated comment", line)
+	}
+
+	text := l.s[l.pos+2 : l.pos+2+end]
+	l.line += strings.Count(text, "\n")
+	l.pos += end + 4
+
+	return token{kind: tokenAnnotation, text: strings.TrimSpace(text), line: line}, nil
+}
+
+// quoted returns the unquoted text of a quoted string.
+func (l *lexer) quoted() (token, error) {
+	line := l.line
+
+	var b strings.Builder
+	for l.pos++; l.pos < len(l.s); l.pos++ {
+		c := l.s[l.pos]
+
+		switch {
+		case c == '"':
+			l.pos++
+			return token{kind: tokenWord, text: b.String(), line: line}, nil
+		case c == '\\' && l.pos+1 < len(l.s):
+			l.pos++
+			c = l.s[l.pos]
+		case c == '\n':
+			l.line++
+		}
+
+		b.WriteByte(c)
+	}
+
+	return token{}, fmt.Errorf("syntax error on line %d - unterminated quoted string", line)
+}
+
+// parser builds the tree from the tokens.
+type parser struct {
+	lex *lexer
+}
+
+// statements parses the statements in a block (or the whole configuration, if not nested)
+// into the children of parent.
+func (p *parser) statements(parent *Node, nested bool) error {
+	annotation := ""
+
+	for {
+		tok, err := p.lex.next()
+		if err != nil {
+			return err
+		}
+
+		switch tok.kind {
+		case tokenEOF:
+			if nested {
+				return fmt.Errorf("syntax error on line %d - missing }", tok.line)
+			}
+
+			return nil
+		case tokenClose:
+			if !nested {
+				return fmt.Errorf("syntax error on line %d - unexpected }", tok.line)
+			}
+
+			return nil
+		case tokenAnnotation:
+			annotation = tok.text
+		case tokenSemicolon:
+		case tokenWord:
+			n := &Node{Annotation: annotation, parent: parent}
+			annotation = ""
+
+			if err := p.statement(n, tok); err != nil {
+				return err
+			}
+
+			parent.Children = append(parent.Children, n)
+		default:
+			return fmt.Errorf("syntax error on line %d - unexpected %s", tok.line, tok.text)
+		}
+	}
+}
+
+// statement parses a statement starting with the given word into n.
+func (p *parser) statement(n *Node, tok token) error {
+	var err error
+
+	for tok.kind == tokenWord {
+		switch {
+		case len(n.Words) == 0 && tok.text == "inactive:":
+			n.Inactive = true
+		case len(n.Words) == 0 && tok.text == "protect:":
+			n.Protect = true
+		case len(n.Words) == 0 && tok.text == "replace:":
+			n.Replace = true
+		default:
+			n.Words = append(n.Words, tok.text)
+		}
+
+		if tok, err = p.lex.next(); err != nil {
+			return err
+		}
+	}
+
+	if len(n.Words) == 0 {
+		return fmt.Errorf("syntax error on line %d - expected a statement", tok.line)
+	}
+
+	switch tok.kind {
+	case tokenSemicolon:
+		return nil
+	case tokenOpen:
+		return p.statements(n, true)
+	case tokenListOpen:
+		return p.list(n)
+	}
+
+	return fmt.Errorf("syntax error on line %d - expected ; or { after %s", tok.line, strings.Join(n.Words, " "))
+}
+
+// list parses the values of a list, e.g. "[ a b ];", into n.
+func (p *parser) list(n *Node) error {
+	for {
+		tok, err := p.lex.next()
+		if err != nil {
+			return err
+		}
+
+		switch tok.kind {
+		case tokenWord:
+			n.Values = append(n.Values, tok.text)
+			continue
+		case tokenListClose:
+			tok, err = p.lex.next()
+			if err != nil {
+				return err
+			}
+
+			if tok.kind != tokenSemicolon {
+				return fmt.Errorf("syntax error on line %d - expected ; after ]", tok.line)
+			}
+
+			return nil
+		}
+
+		return fmt.Errorf("syntax error on line %d - missing ]", tok.line)
+	}
+}
@@ -0,0 +1,384 @@
+package junostest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Canned replies, which are what the server answers with by default. They describe a
+// single routing engine SRX300 named "fw1", running 18.4R2-S3.
+const (
+	SoftwareInformation = `<software-information>
+<host-name>fw1</host-name>
+<product-model>srx300</product-model>
+<product-name>srx300</product-name>
+<junos-version>18.4R2-S3</junos-version>
+<package-information>
+<name>junos</name>
+<comment>JUNOS Software Release [18.4R2-S3]</comment>
+</package-information>
+</software-information>`
+
+	SoftwareInformationMultiRE = `<multi-routing-engine-results>
+<multi-routing-engine-item>
+<re-name>node0</re-name>
+<software-information>
+<host-name>fw1-node0</host-name>
+<product-model>srx345</product-model>
+<product-name>srx345</product-name>
+<junos-version>18.4R2-S3</junos-version>
+<package-information>
+<name>junos</name>
+<comment>JUNOS Software Release [18.4R2-S3]</comment>
+</package-information>
+</software-information>
+</multi-routing-engine-item>
+<multi-routing-engine-item>
+<re-name>node1</re-name>
+<software-information>
+<host-name>fw1-node1</host-name>
+<product-model>srx345</product-model>
+<product-name>srx345</product-name>
+<junos-version>18.4R2-S3</junos-version>
+<package-information>
+<name>junos</name>
+<comment>JUNOS Software Release [18.4R2-S3]</comment>
+</package-information>
+</software-information>
+</multi-routing-engine-item>
+</multi-routing-engine-results>`
+
+	ChassisInventory = `<chassis-inventory>
+<chassis>
+<name>Chassis</name>
+<serial-number>CV0118AF0123</serial-number>
+<description>SRX300</description>
+<chassis-module>
+<name>Routing Engine</name>
+<version>REV 0x10</version>
+<part-number>650-065041</part-number>
+<serial-number>CV0118AF0123</serial-number>
+<description>RE-SRX300</description>
+</chassis-module>
+<chassis-module>
+<name>FPC 0</name>
+<description>FEB</description>
+<chassis-sub-module>
+<name>PIC 0</name>
+<description>8xGE,8xGE SFP Base PIC</description>
+</chassis-sub-module>
+</chassis-module>
+<chassis-module>
+<name>Power Supply 0</name>
+</chassis-module>
+</chassis>
+</chassis-inventory>`
+
+	RouteEngineInformation = `<route-engine-information>
+<route-engine>
+<status>OK</status>
+<temperature>42 degrees C / 107 degrees F</temperature>
+<memory-dram-size>4096 MB</memory-dram-size>
+<memory-buffer-utilization>54</memory-buffer-utilization>
+<cpu-user>2</cpu-user>
+<cpu-idle>95</cpu-idle>
+<model>RE-SRX300</model>
+<start-time>2019-10-01 09:12:44 UTC</start-time>
+<up-time junos:seconds="1221729">14 days, 3 hours, 22 minutes, 9 seconds</up-time>
+<load-average-one>0.08</load-average-one>
+</route-engine>
+</route-engine-information>`
+
+	// ChassisClusterStatus is the chassis cluster state of the SRX345 pair described by
+	// SoftwareInformationMultiRE. By default, the server reports that chassis cluster isn't
+	// enabled.
+	ChassisClusterStatus = `<chassis-cluster-status>
+<cluster-id>1</cluster-id>
+<redundancy-group>
+<cluster-id>1</cluster-id>
+<redundancy-group-id>0</redundancy-group-id>
+<redundancy-group-failover-count>1</redundancy-group-failover-count>
+<device-stats>
+<device-name>node0</device-name>
+<device-priority>200</device-priority>
+<redundancy-group-status>primary</redundancy-group-status>
+<preempt>no</preempt>
+<failover-mode>no</failover-mode>
+<monitor-failures>None</monitor-failures>
+<device-name>node1</device-name>
+<device-priority>100</device-priority>
+<redundancy-group-status>secondary</redundancy-group-status>
+<preempt>no</preempt>
+<failover-mode>no</failover-mode>
+<monitor-failures>None</monitor-failures>
+</device-stats>
+</redundancy-group>
+</chassis-cluster-status>`
+
+	SystemUptimeInformation = `<system-uptime-information>
+<current-time><date-time junos:seconds="1571142893">2019-10-15 12:34:53 UTC</date-time></current-time>
+<system-booted-time><date-time junos:seconds="1569921164">2019-10-01 09:12:44 UTC</date-time></system-booted-time>
+<uptime-information><up-time>14 days, 3:22</up-time></uptime-information>
+</system-uptime-information>`
+
+	Configuration = `<configuration>
+<version>18.4R2-S3</version>
+<system>
+<host-name>fw1</host-name>
+<domain-name>example.net</domain-name>
+<services>
+<ssh/>
+<netconf><ssh/></netconf>
+</services>
+</system>
+<interfaces>
+<interface>
+<name>ge-0/0/0</name>
+<unit>
+<name>0</name>
+<family><inet><address><name>192.0.2.1/24</name></address></inet></family>
+</unit>
+</interface>
+</interfaces>
+</configuration>`
+
+	ConfigurationText = `<configuration-text>
+## Last commit: 2019-10-15 12:30:01 UTC by admin
+version 18.4R2-S3;
+system {
+    host-name fw1;
+    domain-name example.net;
+    services {
+        ssh;
+        netconf {
+            ssh;
+        }
+    }
+}
+interfaces {
+    ge-0/0/0 {
+        unit 0 {
+            family inet {
+                address 192.0.2.1/24;
+            }
+        }
+    }
+}
+</configuration-text>`
+
+	ConfigurationCompare = `<configuration-information>
+<configuration-output>
+[edit system]
+-  host-name fw1;
++  host-name fw2;
+</configuration-output>
+</configuration-information>`
+
+	LoadSuccess = `<load-configuration-results>
+<ok/>
+</load-configuration-results>`
+
+	CommitSuccess = `<commit-results>
+<routing-engine>
+<name>re0</name>
+<commit-success/>
+<commit-revision>re0-1571142601-4</commit-revision>
+</routing-engine>
+</commit-results>`
+
+	CommitInformation = `<commit-information>
+<commit-history>
+<sequence-number>0</sequence-number>
+<user>admin</user>
+<client>netconf</client>
+<date-time junos:seconds="1571142601">2019-10-15 12:30:01 UTC</date-time>
+<log>change hostname</log>
+</commit-history>
+<commit-history>
+<sequence-number>1</sequence-number>
+<user>root</user>
+<client>cli</client>
+<date-time junos:seconds="1571040137">2019-10-14 08:02:17 UTC</date-time>
+</commit-history>
+</commit-information>`
+
+	ArpTableInformation = `<arp-table-information>
+<arp-table-entry>
+<mac-address>00:00:5e:00:53:01</mac-address>
+<ip-address>192.0.2.254</ip-address>
+<hostname>192.0.2.254</hostname>
+<interface-name>ge-0/0/0.0</interface-name>
+</arp-table-entry>
+<arp-entry-count>1</arp-entry-count>
+</arp-table-information>`
+
+	RouteInformation = `<route-information>
+<route-table>
+<table-name>inet.0</table-name>
+<destination-count>2</destination-count>
+<total-route-count>2</total-route-count>
+<active-route-count>2</active-route-count>
+<holddown-route-count>0</holddown-route-count>
+<hidden-route-count>0</hidden-route-count>
+<rt>
+<rt-destination>0.0.0.0/0</rt-destination>
+<rt-entry>
+<active-tag>*</active-tag>
+<protocol-name>Static</protocol-name>
+<preference>5</preference>
+<age>2w0d 03:22:09</age>
+<nh><to>192.0.2.254</to><via>ge-0/0/0.0</via></nh>
+</rt-entry>
+</rt>
+<rt>
+<rt-destination>192.0.2.0/24</rt-destination>
+<rt-entry>
+<active-tag>*</active-tag>
+<protocol-name>Direct</protocol-name>
+<preference>0</preference>
+<age>2w0d 03:22:09</age>
+<nh><via>ge-0/0/0.0</via></nh>
+</rt-entry>
+</rt>
+</route-table>
+</route-information>`
+
+	InterfaceInformation = `<interface-information>
+<physical-interface>
+<name>ge-0/0/0</name>
+<admin-status>up</admin-status>
+<oper-status>up</oper-status>
+<local-index>135</local-index>
+<snmp-index>510</snmp-index>
+<link-level-type>Ethernet</link-level-type>
+<mtu>1514</mtu>
+<speed>1000mbps</speed>
+<hardware-physical-address>00:00:5e:00:53:10</hardware-physical-address>
+<logical-interface>
+<name>ge-0/0/0.0</name>
+<local-index>70</local-index>
+<snmp-index>520</snmp-index>
+<address-family>
+<address-family-name>inet</address-family-name>
+<interface-address><ifa-local>192.0.2.1</ifa-local><ifa-destination>192.0.2/24</ifa-destination></interface-address>
+</address-family>
+</logical-interface>
+</physical-interface>
+</interface-information>`
+)
+
+// RPCError returns an <rpc-error>, which can be used as (or as part of) a reply. Errors
+// with a severity of "error" cause the RPC to fail.
+func RPCError(errType, tag, severity, message string) string {
+	return fmt.Sprintf("<rpc-error><error-type>%s</error-type><error-tag>%s</error-tag><error-severity>%s</error-severity><error-message>%s</error-message></rpc-error>",
+		errType, tag, severity, escape(message))
+}
+
+// CommitError returns the reply to a commit that fails, because of the given error in the
+// configuration at path (e.g. "[edit security policies]") and element.
+func CommitError(path, element, message string) string {
+	return fmt.Sprintf(`<commit-results>
+<rpc-error>
+<error-type>application</error-type>
+<error-tag>invalid-value</error-tag>
+<error-severity>error</error-severity>
+<error-path>%s</error-path>
+<error-info><bad-element>%s</bad-element></error-info>
+<error-message>
+%s
+</error-message>
+</rpc-error>
+<rpc-error>
+<error-type>application</error-type>
+<error-tag>operation-failed</error-tag>
+<error-severity>error</error-severity>
+<error-message>
+configuration check-out failed
+</error-message>
+</rpc-error>
+</commit-results>`, escape(path), escape(element), escape(message))
+}
+
+// LockError returns the reply to a lock-configuration RPC when the configuration is
+// already locked by someone else.
+func LockError() string {
+	return `<rpc-error>
+<error-type>protocol</error-type>
+<error-tag>lock-denied</error-tag>
+<error-severity>error</error-severity>
+<error-message>
+configuration database locked by:
+  admin terminal pts/0 (pid 4242) on since 2019-10-15 12:00:00 UTC
+     exclusive [edit]
+</error-message>
+<error-info><session-id>4242</session-id></error-info>
+</rpc-error>`
+}
+
+// setDefaults installs the default replies.
+func (s *Server) setDefaults() {
+	s.Handle("get-software-information", SoftwareInformation)
+	s.Handle("get-chassis-inventory", ChassisInventory)
+	s.Handle("get-route-engine-information", RouteEngineInformation)
+	s.Handle("get-system-uptime-information", SystemUptimeInformation)
+	s.Handle("get-commit-information", CommitInformation)
+	s.Handle("get-arp-table-information", ArpTableInformation)
+	s.Handle("get-route-information", RouteInformation)
+	s.Handle("get-interface-information", InterfaceInformation)
+	s.Handle("load-configuration", LoadSuccess)
+	s.Handle("commit-configuration", CommitSuccess)
+	s.Handle("get-chassis-cluster-status", RPCError("protocol", "operation-failed", "error", "Chassis cluster is not enabled."))
+
+	for _, rpc := range []string{
+		"lock-configuration", "unlock-configuration", "close-session",
+		"request-save-rescue-configuration", "request-delete-rescue-configuration",
+		"request-reboot", "open-configuration", "close-configuration",
+	} {
+		s.Handle(rpc, "<ok/>")
+	}
+
+	s.HandleFunc("get-configuration", func(req *Request) string {
+		switch {
+		case req.Attrs["compare"] != "":
+			return ConfigurationCompare
+		case req.Attrs["format"] == "text":
+			return ConfigurationText
+		}
+
+		return Configuration
+	})
+
+	s.HandleFunc("command", s.command)
+}
+
+// command answers the <command> RPC from the outputs set with HandleCommand.
+func (s *Server) command(req *Request) string {
+	var cmd struct {
+		Text string `xml:",chardata"`
+	}
+	xml.Unmarshal([]byte(req.XML), &cmd)
+
+	s.mu.Lock()
+	output, ok := s.commands[strings.TrimSpace(cmd.Text)]
+	s.mu.Unlock()
+
+	if !ok {
+		return RPCError("protocol", "operation-failed", "error", "syntax error, expecting <command>.")
+	}
+
+	if req.Attrs["format"] == "text" {
+		return "<output>\n" + escape(output) + "\n</output>"
+	}
+
+	return output
+}
+
+// escape returns s, escaped for use as XML character data.
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+
+	return buf.String()
+}
@@ -0,0 +1,507 @@
+// Package junostest provides an in-process Junos NETCONF server, for testing code that uses
+// the junos package without a real device.
+//
+// The server listens on localhost, speaks NETCONF over SSH (or TLS, see NewTLSServer), and
+// answers each RPC with a canned XML reply. Replies for the common RPCs (software information,
+// chassis inventory, configuration, commits, etc.) are set up by default, and can be replaced
+// with Handle or HandleFunc. Every RPC the server receives is recorded, so tests can assert on
+// what was sent.
+//
+//	srv := junostest.NewServer()
+//	defer srv.Close()
+//
+//	jnpr, err := junos.NewSession(srv.Addr, srv.Auth())
+package junostest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/scottdware/go-junos"
+	"golang.org/x/crypto/ssh"
+)
+
+const msgSeparator = "]]>]]>"
+
+// DefaultCapabilities are the capabilities the server advertises in its hello, unless
+// Server.Capabilities is changed.
+var DefaultCapabilities = []string{
+	"urn:ietf:params:netconf:base:1.0",
+	"urn:ietf:params:netconf:capability:candidate:1.0",
+	"urn:ietf:params:netconf:capability:confirmed-commit:1.0",
+	"urn:ietf:params:netconf:capability:validate:1.0",
+	"urn:ietf:params:netconf:capability:url:1.0?scheme=http,ftp,file",
+	"urn:ietf:params:xml:ns:netconf:base:1.0",
+	"urn:ietf:params:xml:ns:netconf:capability:candidate:1.0",
+	"urn:ietf:params:xml:ns:netconf:capability:confirmed-commit:1.0",
+	"urn:ietf:params:xml:ns:netconf:capability:validate:1.0",
+	"urn:ietf:params:xml:ns:netconf:capability:url:1.0?protocol=http,ftp,file",
+	"http://xml.juniper.net/netconf/junos/1.0",
+	"http://xml.juniper.net/dmi/system/1.0",
+}
+
+// Request is an RPC received by the server. Name is the name of the RPC's element (e.g.
+// "get-software-information"), Attrs holds the element's attributes, and XML is the
+// RPC itself, without the surrounding <rpc> element.
+type Request struct {
+	Name  string
+	Attrs map[string]string
+	XML   string
+}
+
+// HandlerFunc returns the contents of the <rpc-reply> for a request.
+type HandlerFunc func(req *Request) string
+
+// Server is an in-process Junos NETCONF server. Addr is the address it's listening on, and
+// Username and Password are the credentials it accepts, using either password or
+// keyboard-interactive authentication. AuthorizedKeys are the public keys it accepts for
+// Username, and DisablePasswordAuth turns password authentication off, leaving only
+// keyboard-interactive and public keys (as on devices set up that way). These, and
+// Capabilities, can be changed before any sessions are established.
+type Server struct {
+	Addr                string
+	Username            string
+	Password            string
+	AuthorizedKeys      []ssh.PublicKey
+	DisablePasswordAuth bool
+	Capabilities        []string
+
+	listener net.Listener
+	tls      bool
+	hostKey  ssh.Signer
+	config   *ssh.ServerConfig
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc
+	commands map[string]string
+	requests []Request
+	conns    map[net.Conn]bool
+	sessions int
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a server listening on a random port on localhost, answering with the
+// default replies. It panics if the server can't be started, the same as httptest.NewServer.
+func NewServer() *Server {
+	return newServer(listen(), false)
+}
+
+// NewTLSServer starts a server speaking NETCONF over TLS (RFC 7589) instead of SSH, using the
+// given config, which must contain the server's certificate. Set config.ClientAuth and
+// config.ClientCAs to require, and verify, a client certificate.
+func NewTLSServer(config *tls.Config) *Server {
+	return newServer(tls.NewListener(listen(), config), true)
+}
+
+// listen listens on a random port on localhost.
+func listen() net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("junostest: failed to listen on a port: %v", err))
+	}
+
+	return l
+}
+
+// newServer starts a server accepting connections on l, which are TLS connections if
+// overTLS is set, and SSH connections otherwise.
+func newServer(l net.Listener, overTLS bool) *Server {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(fmt.Sprintf("junostest: failed to generate host key: %v", err))
+	}
+
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		panic(fmt.Sprintf("junostest: failed to generate host key: %v", err))
+	}
+
+	s := &Server{
+		Addr:         l.Addr().String(),
+		Username:     "admin",
+		Password:     "Juniper123!",
+		Capabilities: DefaultCapabilities,
+		listener:     l,
+		tls:          overTLS,
+		hostKey:      signer,
+		handlers:     map[string]HandlerFunc{},
+		commands:     map[string]string{},
+		conns:        map[net.Conn]bool{},
+	}
+
+	s.config = &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if !s.DisablePasswordAuth && c.User() == s.Username && string(password) == s.Password {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("invalid credentials for %s", c.User())
+		},
+		KeyboardInteractiveCallback: func(c ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := challenge("", "", []string{"Password:"}, []bool{false})
+			if err == nil && len(answers) == 1 && c.User() == s.Username && answers[0] == s.Password {
+				return nil, nil
+			}
+
+			return nil, fmt.Errorf("invalid credentials for %s", c.User())
+		},
+		PublicKeyCallback: func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			for _, k := range s.AuthorizedKeys {
+				if c.User() == s.Username && bytes.Equal(k.Marshal(), key.Marshal()) {
+					return nil, nil
+				}
+			}
+
+			return nil, fmt.Errorf("unauthorized key for %s", c.User())
+		},
+	}
+	s.config.AddHostKey(signer)
+
+	s.setDefaults()
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s
+}
+
+// Auth returns the authentication method to use with junos.NewSession, which also pins
+// the server's host key.
+func (s *Server) Auth() *junos.AuthMethod {
+	return &junos.AuthMethod{
+		Credentials:         []string{s.Username, s.Password},
+		HostKeyFingerprints: []string{ssh.FingerprintSHA256(s.hostKey.PublicKey())},
+	}
+}
+
+// HostKey returns the server's SSH host key.
+func (s *Server) HostKey() ssh.PublicKey {
+	return s.hostKey.PublicKey()
+}
+
+// Handle sets the contents of the <rpc-reply> returned for the named RPC.
+func (s *Server) Handle(rpc, reply string) {
+	s.HandleFunc(rpc, func(*Request) string {
+		return reply
+	})
+}
+
+// HandleFunc sets the function that answers the named RPC.
+func (s *Server) HandleFunc(rpc string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[rpc] = fn
+}
+
+// HandleCommand sets the output returned for the given operational mode command, when it's
+// run using the <command> RPC (i.e. junos.Command). The output is returned as is for
+// format="xml", and wrapped in <output> for format="text".
+func (s *Server) HandleCommand(cmd, output string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commands[cmd] = output
+}
+
+// Requests returns every RPC the server has received, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reqs := make([]Request, len(s.requests))
+	copy(reqs, s.requests)
+
+	return reqs
+}
+
+// Received returns the number of times the named RPC has been received.
+func (s *Server) Received(rpc string) int {
+	n := 0
+	for _, r := range s.Requests() {
+		if r.Name == rpc {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Sessions returns the number of NETCONF sessions that have been established.
+func (s *Server) Sessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sessions
+}
+
+// Reset forgets the RPCs received so far.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = nil
+}
+
+// CloseClientConnections drops every established connection, which is useful for testing
+// how a client copes with its transport failing.
+func (s *Server) CloseClientConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.conns {
+		c.Close()
+	}
+}
+
+// Close stops the server, and drops every established connection.
+func (s *Server) Close() {
+	s.listener.Close()
+	s.CloseClientConnections()
+	s.wg.Wait()
+}
+
+// DialOutbound connects to an outbound-ssh server (such as junos.OutboundServer) at addr, the
+// same as a device configured with "system services outbound-ssh", and serves the SSH
+// connection the server then establishes. The preamble identifies the server as deviceID.
+// If secret is set, the preamble also includes the server's host key, and its HMAC using
+// secret.
+func (s *Server) DialOutbound(addr, deviceID, secret string) error {
+	nc, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	preamble := fmt.Sprintf("MSG-ID: DEVICE-CONN-INFO\r\nMSG-VER: V1\r\nDEVICE-ID: %s\r\n", deviceID)
+	if secret != "" {
+		key := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(s.hostKey.PublicKey())))
+
+		mac := hmac.New(sha1.New, []byte(secret))
+		mac.Write([]byte(key))
+		preamble += fmt.Sprintf("HOST-KEY: %s\r\nHMAC: %s\r\n", key, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	if _, err := io.WriteString(nc, preamble); err != nil {
+		nc.Close()
+		return err
+	}
+
+	s.goServeConn(nc)
+
+	return nil
+}
+
+// serve accepts connections until the listener is closed.
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		nc, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.goServeConn(nc)
+	}
+}
+
+// goServeConn serves the connection in its own goroutine, until it's closed or dropped.
+func (s *Server) goServeConn(nc net.Conn) {
+	s.mu.Lock()
+	s.conns[nc] = true
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.serveConn(nc)
+
+		s.mu.Lock()
+		delete(s.conns, nc)
+		s.mu.Unlock()
+	}()
+}
+
+// serveConn handles the SSH connection, starting a NETCONF session for each netconf
+// subsystem request. For TLS servers, the NETCONF session runs on the connection itself.
+func (s *Server) serveConn(nc net.Conn) {
+	defer nc.Close()
+
+	if s.tls {
+		s.serveNetconf(nc)
+		return
+	}
+
+	conn, chans, reqs, err := ssh.NewServerConn(nc, s.config)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	go ssh.DiscardRequests(reqs)
+
+	for newCh := range chans {
+		if newCh.ChannelType() != "session" {
+			newCh.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+
+		ch, chReqs, err := newCh.Accept()
+		if err != nil {
+			continue
+		}
+
+		go func() {
+			for req := range chReqs {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "netconf"
+				req.Reply(ok, nil)
+
+				if ok {
+					go func() {
+						s.serveNetconf(ch)
+						ch.Close()
+					}()
+				}
+			}
+		}()
+	}
+}
+
+// serveNetconf exchanges hellos, and answers RPCs until the session is closed.
+func (s *Server) serveNetconf(rw io.ReadWriter) {
+	s.mu.Lock()
+	s.sessions++
+	id := s.sessions
+	caps := s.Capabilities
+	s.mu.Unlock()
+
+	var hello bytes.Buffer
+	hello.WriteString(`<hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>`)
+	for _, c := range caps {
+		hello.WriteString("<capability>")
+		xml.EscapeText(&hello, []byte(c))
+		hello.WriteString("</capability>")
+	}
+	fmt.Fprintf(&hello, "</capabilities><session-id>%d</session-id></hello>", id)
+
+	if err := send(rw, hello.Bytes()); err != nil {
+		return
+	}
+
+	r := bufio.NewReader(rw)
+	if _, err := receive(r); err != nil {
+		return
+	}
+
+	for {
+		msg, err := receive(r)
+		if err != nil {
+			return
+		}
+
+		messageID, req, err := parseRPC(msg)
+		if err != nil {
+			continue
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, *req)
+		s.mu.Unlock()
+
+		reply := fmt.Sprintf(`<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" xmlns:junos="http://xml.juniper.net/junos/18.4R2/junos" message-id="%s">%s</rpc-reply>`, messageID, s.reply(req))
+		if err := send(rw, []byte(reply)); err != nil {
+			return
+		}
+
+		if req.Name == "close-session" {
+			return
+		}
+	}
+}
+
+// reply returns the contents of the <rpc-reply> for the request.
+func (s *Server) reply(req *Request) string {
+	s.mu.Lock()
+	fn := s.handlers[req.Name]
+	s.mu.Unlock()
+
+	if fn == nil {
+		return RPCError("protocol", "operation-failed", "error", fmt.Sprintf("syntax error, expecting <command> (%s)", req.Name))
+	}
+
+	return fn(req)
+}
+
+// send writes a message, followed by the end-of-message separator.
+func send(w io.Writer, msg []byte) error {
+	_, err := w.Write(append(msg, msgSeparator+"\n"...))
+
+	return err
+}
+
+// receive reads the next message, without the end-of-message separator.
+func receive(r *bufio.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for {
+		b, err := r.ReadSlice('>')
+		buf.Write(b)
+
+		if bytes.HasSuffix(buf.Bytes(), []byte(msgSeparator)) {
+			return bytes.TrimSpace(buf.Bytes()[:buf.Len()-len(msgSeparator)]), nil
+		}
+
+		if err != nil && err != bufio.ErrBufferFull {
+			return nil, err
+		}
+	}
+}
+
+// parseRPC returns the message-id of the RPC, and the request it contains.
+func parseRPC(msg []byte) (string, *Request, error) {
+	var rpc struct {
+		MessageID string `xml:"message-id,attr"`
+		Inner     []byte `xml:",innerxml"`
+	}
+
+	if err := xml.Unmarshal(msg, &rpc); err != nil {
+		return "", nil, err
+	}
+
+	req := &Request{
+		Attrs: map[string]string{},
+		XML:   strings.TrimSpace(string(rpc.Inner)),
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(rpc.Inner))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", nil, fmt.Errorf("empty rpc - %s", err)
+		}
+
+		if se, ok := tok.(xml.StartElement); ok {
+			req.Name = se.Name.Local
+			for _, a := range se.Attr {
+				req.Attrs[a.Name.Local] = a.Value
+			}
+
+			break
+		}
+	}
+
+	return rpc.MessageID, req, nil
+}
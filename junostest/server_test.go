@@ -0,0 +1,158 @@
+package junostest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+// newSession returns a session connected to a new test server.
+func newSession(t *testing.T) (*junos.Junos, *junostest.Server) {
+	t.Helper()
+
+	srv := junostest.NewServer()
+	t.Cleanup(srv.Close)
+
+	j, err := junos.NewSession(srv.Addr, srv.Auth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(j.Close)
+
+	return j, srv
+}
+
+func TestServerFacts(t *testing.T) {
+	j, srv := newSession(t)
+
+	if j.Hostname != "fw1" || j.RoutingEngines != 1 {
+		t.Errorf("got hostname %q with %d routing engines", j.Hostname, j.RoutingEngines)
+	}
+
+	if len(j.Platform) != 1 || j.Platform[0].Version != "18.4R2-S3" {
+		t.Errorf("got platform %+v", j.Platform)
+	}
+
+	if srv.Sessions() != 1 || srv.Received("get-software-information") != 1 {
+		t.Errorf("got %d sessions, and requests %+v", srv.Sessions(), srv.Requests())
+	}
+}
+
+func TestServerMultiRoutingEngine(t *testing.T) {
+	srv := junostest.NewServer()
+	defer srv.Close()
+	srv.Handle("get-software-information", junostest.SoftwareInformationMultiRE)
+
+	j, err := junos.NewSession(srv.Addr, srv.Auth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	if j.RoutingEngines != 2 {
+		t.Errorf("got %d routing engines, want 2", j.RoutingEngines)
+	}
+}
+
+func TestServerBadCredentials(t *testing.T) {
+	srv := junostest.NewServer()
+	defer srv.Close()
+
+	auth := srv.Auth()
+	auth.Credentials[1] = "wrong"
+
+	if j, err := junos.NewSession(srv.Addr, auth); err == nil {
+		j.Close()
+		t.Fatal("NewSession succeeded with the wrong password")
+	}
+}
+
+func TestServerHandle(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Reset()
+
+	srv.HandleFunc("get-alarm-information", func(req *junostest.Request) string {
+		if req.Attrs["format"] != "" {
+			t.Errorf("got attributes %v", req.Attrs)
+		}
+
+		return "<alarm-information><alarm-summary><no-active-alarms/></alarm-summary></alarm-information>"
+	})
+
+	var reply struct {
+		Summary struct {
+			None *struct{} `xml:"no-active-alarms"`
+		} `xml:"alarm-summary"`
+	}
+	if err := j.RPC("<get-alarm-information/>", &reply); err != nil {
+		t.Fatal(err)
+	}
+
+	if reply.Summary.None == nil {
+		t.Error("the handler's reply wasn't returned")
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 1 || reqs[0].Name != "get-alarm-information" || !strings.Contains(reqs[0].XML, "<get-alarm-information") {
+		t.Errorf("got requests %+v", reqs)
+	}
+
+	srv.Reset()
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("got %d requests after Reset", n)
+	}
+}
+
+func TestServerUnknownRPC(t *testing.T) {
+	j, _ := newSession(t)
+
+	if err := j.RPC("<get-nothing-at-all/>", nil); err == nil {
+		t.Error("an RPC without a handler succeeded")
+	}
+}
+
+func TestServerCommand(t *testing.T) {
+	j, srv := newSession(t)
+	srv.HandleCommand("show version", "Hostname: fw1")
+
+	out, err := j.Command("show version", "text")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out, "Hostname: fw1") {
+		t.Errorf("got %q", out)
+	}
+
+	if _, err := j.Command("show bogus"); err == nil {
+		t.Error("a command without any output set succeeded")
+	}
+}
+
+func TestServerCapabilities(t *testing.T) {
+	srv := junostest.NewServer()
+	defer srv.Close()
+	srv.Capabilities = []string{"urn:ietf:params:netconf:base:1.0"}
+
+	j, err := junos.NewSession(srv.Addr, srv.Auth())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	if j.Supports(junos.CapabilityCandidate) {
+		t.Error("the session has a capability the server didn't advertise")
+	}
+}
+
+func TestServerCloseClientConnections(t *testing.T) {
+	j, srv := newSession(t)
+
+	srv.CloseClientConnections()
+
+	if err := j.Ping(); err == nil {
+		t.Error("Ping succeeded after the server dropped the connection")
+	}
+}
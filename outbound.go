@@ -0,0 +1,332 @@
+package junos
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// outboundTimeout is how long a device has to send its outbound-ssh preamble, and for us
+// to establish the NETCONF session and gather its facts, once it has connected.
+const outboundTimeout = 30 * time.Second
+
+// ErrOutboundServerClosed is returned by ListenAndServe and Serve once the server is closed.
+var ErrOutboundServerClosed = errors.New("outbound-ssh server closed")
+
+// OutboundDevice contains the information a device sends when it connects to us using
+// outbound-ssh. HostKey and HMAC are only sent if the device is configured to send them.
+type OutboundDevice struct {
+	DeviceID   string
+	HostKey    string
+	HMAC       string
+	RemoteAddr net.Addr
+}
+
+// OutboundServer accepts connections from devices configured with "system services
+// outbound-ssh", which is useful for devices that can only dial out, e.g. those behind
+// NAT. Once a device connects, we authenticate to it using Auth (which also sets whether
+// MinimalFacts are gathered), and the session is handed to Handler (in its own
+// goroutine). Handler owns the session, and must close it when it's done.
+//
+// If Secret is set, it must match the secret configured on the device. The HMAC the
+// device sends is then verified, and the device's SSH host key must match the one it
+// sent in the preamble.
+//
+// If set, ErrorHandler is called whenever a device fails to connect, including when it
+// takes longer than 30 seconds to send its preamble and establish the session.
+type OutboundServer struct {
+	Auth         *AuthMethod
+	Secret       string
+	Handler      func(j *Junos, device *OutboundDevice)
+	ErrorHandler func(addr net.Addr, err error)
+
+	mu        sync.Mutex
+	closed    bool
+	listeners map[net.Listener]bool
+	conns     map[net.Conn]bool
+}
+
+// ListenAndServe listens for outbound-ssh connections on the given TCP address, and serves
+// them. Junos devices connect on port 2200 by default.
+func (s *OutboundServer) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	return s.Serve(l)
+}
+
+// Serve accepts outbound-ssh connections on the listener until it's closed, or the server
+// is closed.
+func (s *OutboundServer) Serve(l net.Listener) error {
+	if s.Auth == nil || s.Handler == nil {
+		return errors.New("an outbound-ssh server requires both Auth and Handler")
+	}
+
+	if !s.track(l, true) {
+		return ErrOutboundServerClosed
+	}
+	defer s.track(l, false)
+
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrOutboundServerClosed
+			}
+
+			if ne, ok := err.(net.Error); ok && ne.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+
+			return err
+		}
+
+		go s.serveConn(nc)
+	}
+}
+
+// Close stops the server: its listeners are closed, and connections from devices that are
+// still being set up are dropped. Sessions that have already been handed to Handler are
+// left alone, since Handler owns them.
+func (s *OutboundServer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+
+	var err error
+	for l := range s.listeners {
+		if cerr := l.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	for nc := range s.conns {
+		nc.Close()
+	}
+
+	return err
+}
+
+// isClosed returns whether the server has been closed.
+func (s *OutboundServer) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+// track adds (or removes) a listener or connection to the ones closed by Close. It returns
+// false if the server has already been closed, in which case nothing is added.
+func (s *OutboundServer) track(c io.Closer, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listeners == nil {
+		s.listeners = map[net.Listener]bool{}
+		s.conns = map[net.Conn]bool{}
+	}
+
+	switch c := c.(type) {
+	case net.Listener:
+		if add && !s.closed {
+			s.listeners[c] = true
+		} else {
+			delete(s.listeners, c)
+		}
+	case net.Conn:
+		if add && !s.closed {
+			s.conns[c] = true
+		} else {
+			delete(s.conns, c)
+		}
+	}
+
+	return !s.closed
+}
+
+// serveConn reads the preamble from the device, and establishes the NETCONF session.
+func (s *OutboundServer) serveConn(nc net.Conn) {
+	j, device, err := s.newSession(nc)
+	if err != nil {
+		nc.Close()
+		if s.ErrorHandler != nil {
+			s.ErrorHandler(nc.RemoteAddr(), err)
+		}
+
+		return
+	}
+
+	s.Handler(j, device)
+}
+
+// newSession reads the preamble from the device, and establishes the NETCONF session,
+// giving up if it takes longer than outboundTimeout, or the server is closed.
+func (s *OutboundServer) newSession(nc net.Conn) (*Junos, *OutboundDevice, error) {
+	if !s.track(nc, true) {
+		return nil, nil, ErrOutboundServerClosed
+	}
+	defer s.track(nc, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), outboundTimeout)
+	defer cancel()
+
+	nc.SetReadDeadline(time.Now().Add(outboundTimeout))
+
+	r := bufio.NewReader(nc)
+	device, err := readOutboundPreamble(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	device.RemoteAddr = nc.RemoteAddr()
+
+	nc.SetReadDeadline(time.Time{})
+
+	config, release, err := genSSHClientConfig(s.Auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	if len(s.Secret) > 0 {
+		key, err := device.verify(s.Secret)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		config.HostKeyCallback = ssh.FixedHostKey(key)
+	}
+
+	j, err := newSessionFromNetConn(ctx, device.DeviceID, &bufferedConn{nc, r}, config, sessionConfig{minimalFacts: s.Auth.MinimalFacts})
+	if err == nil && s.isClosed() {
+		err = ErrOutboundServerClosed
+	}
+
+	if err != nil {
+		if j != nil {
+			j.Close()
+		}
+
+		return nil, nil, err
+	}
+
+	return j, device, nil
+}
+
+// verify checks the HMAC sent by the device, using the shared secret, and returns the
+// host key it sent.
+func (d *OutboundDevice) verify(secret string) (ssh.PublicKey, error) {
+	if d.HostKey == "" || d.HMAC == "" {
+		return nil, fmt.Errorf("device %s did not send its host key and HMAC", d.DeviceID)
+	}
+
+	mac := hmac.New(sha1.New, []byte(secret))
+	mac.Write([]byte(d.HostKey))
+	want := hex.EncodeToString(mac.Sum(nil))
+
+	if !hmac.Equal([]byte(strings.ToLower(d.HMAC)), []byte(want)) {
+		return nil, fmt.Errorf("HMAC mismatch for device %s", d.DeviceID)
+	}
+
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(d.HostKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid host key sent by device %s - %s", d.DeviceID, err)
+	}
+
+	return key, nil
+}
+
+// readOutboundPreamble reads the connection information a device sends before the SSH
+// handshake starts:
+//
+// MSG-ID: DEVICE-CONN-INFO
+// MSG-VER: V1
+// DEVICE-ID: <device-id>
+// HOST-KEY: <pub-host-key>
+// HMAC: <HMAC(pub-SSH-host-key, <secret>)>
+//
+// The HOST-KEY and HMAC lines are optional.
+func readOutboundPreamble(r *bufio.Reader) (*OutboundDevice, error) {
+	device := &OutboundDevice{}
+	seen := map[string]bool{}
+
+	for {
+		if seen["DEVICE-ID"] {
+			next, err := r.Peek(4)
+			if err != nil {
+				return nil, err
+			}
+
+			if bytes.Equal(next, []byte("SSH-")) {
+				break
+			}
+		}
+
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("error reading outbound-ssh preamble - %s", err)
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid outbound-ssh preamble line %q", line)
+		}
+
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		seen[key] = true
+
+		switch key {
+		case "MSG-ID":
+			if value != "DEVICE-CONN-INFO" {
+				return nil, fmt.Errorf("unexpected outbound-ssh message %q", value)
+			}
+		case "MSG-VER":
+			if value != "V1" {
+				return nil, fmt.Errorf("unsupported outbound-ssh version %q", value)
+			}
+		case "DEVICE-ID":
+			device.DeviceID = value
+		case "HOST-KEY":
+			device.HostKey = value
+		case "HMAC":
+			device.HMAC = value
+		}
+	}
+
+	if !seen["MSG-ID"] || device.DeviceID == "" {
+		return nil, errors.New("incomplete outbound-ssh preamble")
+	}
+
+	return device, nil
+}
+
+// bufferedConn reads from r, which has already buffered data from the connection.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
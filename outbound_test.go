@@ -0,0 +1,194 @@
+package junos_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scottdware/go-junos"
+)
+
+// outbound is an outbound-ssh server under test, with the sessions handed to its Handler,
+// and the errors passed to its ErrorHandler.
+type outbound struct {
+	*junos.OutboundServer
+	addr     string
+	sessions chan *junos.Junos
+	devices  chan *junos.OutboundDevice
+	errs     chan error
+	served   chan error
+}
+
+// newOutbound starts an outbound-ssh server, which is closed when the test finishes.
+func newOutbound(t *testing.T, auth *junos.AuthMethod, secret string) *outbound {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := &outbound{
+		addr:     l.Addr().String(),
+		sessions: make(chan *junos.Junos, 1),
+		devices:  make(chan *junos.OutboundDevice, 1),
+		errs:     make(chan error, 1),
+		served:   make(chan error, 1),
+	}
+
+	o.OutboundServer = &junos.OutboundServer{
+		Auth:   auth,
+		Secret: secret,
+		Handler: func(j *junos.Junos, device *junos.OutboundDevice) {
+			o.sessions <- j
+			o.devices <- device
+		},
+		ErrorHandler: func(addr net.Addr, err error) {
+			o.errs <- err
+		},
+	}
+
+	go func() {
+		o.served <- o.Serve(l)
+	}()
+	t.Cleanup(func() { o.Close() })
+
+	return o
+}
+
+// session returns the next session handed to the handler.
+func (o *outbound) session(t *testing.T) (*junos.Junos, *junos.OutboundDevice) {
+	t.Helper()
+
+	select {
+	case j := <-o.sessions:
+		t.Cleanup(j.Close)
+		return j, <-o.devices
+	case err := <-o.errs:
+		t.Fatalf("the device failed to connect - %s", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the device never connected")
+	}
+
+	return nil, nil
+}
+
+// err returns the next error passed to the error handler.
+func (o *outbound) err(t *testing.T) error {
+	t.Helper()
+
+	select {
+	case j := <-o.sessions:
+		j.Close()
+		t.Fatal("the device connected")
+	case err := <-o.errs:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("the error handler wasn't called")
+	}
+
+	return nil
+}
+
+func TestOutboundServer(t *testing.T) {
+	srv := newServer(t)
+	o := newOutbound(t, srv.Auth(), "")
+
+	if err := srv.DialOutbound(o.addr, "branch-1", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	j, device := o.session(t)
+	if device.DeviceID != "branch-1" || device.HostKey != "" {
+		t.Errorf("the device was identified as %+v", device)
+	}
+
+	if j.Hostname == "" {
+		t.Error("the facts weren't gathered")
+	}
+
+	if err := j.Ping(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOutboundServerSecret(t *testing.T) {
+	srv := newServer(t)
+	auth := srv.Auth()
+	auth.HostKeyFingerprints = nil
+	o := newOutbound(t, auth, "s3cret")
+
+	if err := srv.DialOutbound(o.addr, "branch-1", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, device := o.session(t)
+	if device.HostKey == "" || device.HMAC == "" {
+		t.Errorf("the device didn't send its host key and HMAC: %+v", device)
+	}
+
+	if err := srv.DialOutbound(o.addr, "branch-2", "wrong"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := o.err(t); !strings.Contains(err.Error(), "HMAC mismatch") {
+		t.Errorf("a device with the wrong secret failed with %v", err)
+	}
+
+	if err := srv.DialOutbound(o.addr, "branch-3", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := o.err(t); !strings.Contains(err.Error(), "did not send its host key") {
+		t.Errorf("a device without a secret failed with %v", err)
+	}
+}
+
+func TestOutboundServerClose(t *testing.T) {
+	srv := newServer(t)
+	o := newOutbound(t, srv.Auth(), "")
+
+	// A device that connects, but never sends its preamble.
+	nc, err := net.Dial("tcp", o.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer nc.Close()
+
+	if err := srv.DialOutbound(o.addr, "branch-1", ""); err != nil {
+		t.Fatal(err)
+	}
+	j, _ := o.session(t)
+
+	// Give the server a moment to accept the silent connection.
+	time.Sleep(50 * time.Millisecond)
+
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-o.served:
+		if err != junos.ErrOutboundServerClosed {
+			t.Errorf("Serve returned %v, want %v", err, junos.ErrOutboundServerClosed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve didn't return after Close")
+	}
+
+	nc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := nc.Read(make([]byte, 1)); err == nil {
+		t.Fatal("read data from a connection Close should have dropped")
+	} else if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		t.Error("Close didn't drop a connection that was still being set up")
+	}
+
+	if err := j.Ping(); err != nil {
+		t.Errorf("Close dropped a session that was already handed to Handler - %s", err)
+	}
+
+	if err := o.Serve(nil); err != junos.ErrOutboundServerClosed {
+		t.Errorf("Serve on a closed server returned %v", err)
+	}
+}
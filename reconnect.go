@@ -0,0 +1,267 @@
+package junos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// errSessionClosed is returned when a session is closed while it's being re-established.
+var errSessionClosed = errors.New("session is closed")
+
+// ReconnectOptions controls how a long-lived session is kept alive, and re-established when
+// its transport fails. See EnableReconnect.
+//
+// KeepAlive is the interval between SSH keepalives; if it's zero, keepalives aren't sent.
+// MaxAttempts is the number of times we try to re-dial the device before giving up (3 by
+// default), waiting MinBackoff (1 second by default) after the first failed attempt, and
+// doubling that up to MaxBackoff (30 seconds by default) after each one after that.
+//
+// If set, OnReconnect is called after the session has been re-established.
+type ReconnectOptions struct {
+	KeepAlive   time.Duration
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	OnReconnect func(j *Junos)
+}
+
+// reconnector holds the reconnect state for a session. dead is guarded by Junos.mu.
+type reconnector struct {
+	opts ReconnectOptions
+	dead bool
+	stop chan struct{}
+	once sync.Once
+}
+
+// close stops the keepalives, and any reconnect in progress.
+func (rc *reconnector) close() {
+	rc.once.Do(func() {
+		close(rc.stop)
+	})
+}
+
+// reconnected calls the OnReconnect hook, if there is one.
+func (rc *reconnector) reconnected(j *Junos) {
+	if rc.opts.OnReconnect != nil {
+		rc.opts.OnReconnect(j)
+	}
+}
+
+// EnableReconnect keeps the session alive, and transparently re-establishes it if its
+// transport fails, e.g. when the SSH connection drops. The RPC that was in flight when the
+// transport failed still returns an error, but the next one re-dials the device (re-running
+// GatherFacts) before it's sent. With keepalives enabled, a failed transport is noticed,
+// and re-established, without waiting for the next RPC.
+//
+// This is only supported for sessions created with NewSession, NewSessionWithConfig or
+// NewSessionTLS, since we must know how to dial the device again. Keepalives are only sent
+// on SSH sessions.
+func (j *Junos) EnableReconnect(opts *ReconnectOptions) error {
+	if j.redial == nil {
+		return errors.New("reconnect is only supported for sessions created with NewSession, NewSessionWithConfig or NewSessionTLS")
+	}
+
+	var o ReconnectOptions
+	if opts != nil {
+		o = *opts
+	}
+
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = time.Second
+	}
+
+	if o.MaxBackoff < o.MinBackoff {
+		o.MaxBackoff = 30 * time.Second
+		if o.MaxBackoff < o.MinBackoff {
+			o.MaxBackoff = o.MinBackoff
+		}
+	}
+
+	rc := &reconnector{
+		opts: o,
+		stop: make(chan struct{}),
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.rc != nil {
+		return errors.New("reconnect is already enabled for this session")
+	}
+
+	j.connMu.Lock()
+	j.rc = rc
+	j.connMu.Unlock()
+
+	if o.KeepAlive > 0 {
+		go j.keepAlive(rc)
+	}
+
+	return nil
+}
+
+// Ping checks that the device is still responding to RPCs. If reconnects are enabled, and
+// the session's transport has failed, the session is re-established first.
+func (j *Junos) Ping() error {
+	return j.PingContext(context.Background())
+}
+
+// PingContext is the same as Ping, but stops waiting on the device once the given context
+// is done.
+func (j *Junos) PingContext(ctx context.Context) error {
+	_, err := j.exec(ctx, rpcPing)
+
+	return err
+}
+
+// reconnect re-dials the device, with backoff, and swaps the new session in place of the
+// failed one. It must be called with j.mu held.
+func (j *Junos) reconnect(ctx context.Context) error {
+	rc := j.rc
+	backoff := rc.opts.MinBackoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var nj *Junos
+		nj, err = j.redial(ctx, sessionConfig{
+			minimalFacts: j.MinimalFacts,
+			fleetLimit:   j.fleetLimit,
+			rateLimit:    j.RateLimit,
+			retryPolicy:  j.RetryPolicy,
+		})
+		if err == nil {
+			j.connMu.Lock()
+			select {
+			case <-rc.stop:
+				j.connMu.Unlock()
+				nj.closeTransport()
+				return errSessionClosed
+			default:
+			}
+
+			if j.recording != nil {
+				nj.Session.Transport = j.recording.wrap(nj.Session.Transport)
+			}
+
+			old, oldClosers := j.Session, j.closers
+			j.Session, j.closers = nj.Session, nj.closers
+			j.connMu.Unlock()
+
+			old.Transport.Close()
+			closeAll(oldClosers)
+
+			j.Hostname = nj.Hostname
+			j.RoutingEngines = nj.RoutingEngines
+			j.Platform = nj.Platform
+			j.Capabilities = nj.Capabilities
+			j.Facts = nj.Facts
+			rc.dead = false
+
+			return nil
+		}
+
+		if nj != nil {
+			nj.Close()
+		}
+
+		if attempt >= rc.opts.MaxAttempts {
+			break
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-rc.stop:
+			return errSessionClosed
+		}
+
+		backoff *= 2
+		if backoff > rc.opts.MaxBackoff {
+			backoff = rc.opts.MaxBackoff
+		}
+	}
+
+	return fmt.Errorf("unable to reconnect to %s after %d attempts - %s", j.Hostname, rc.opts.MaxAttempts, err)
+}
+
+// keepAlive sends SSH keepalives on the session until it's closed. If a keepalive fails,
+// the transport is closed (so any pending RPC fails straight away) and re-established.
+func (j *Junos) keepAlive(rc *reconnector) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-rc.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(rc.opts.KeepAlive)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rc.stop:
+			return
+		case <-ticker.C:
+		}
+
+		j.connMu.Lock()
+		s := j.Session
+		j.connMu.Unlock()
+
+		t, ok := unwrapTransport(s.Transport).(*transport)
+		if !ok || t.client == nil {
+			continue
+		}
+
+		if err := sendKeepAlive(t.client, rc.opts.KeepAlive); err == nil {
+			continue
+		}
+
+		t.Close()
+
+		j.mu.Lock()
+		if j.Session != s {
+			j.mu.Unlock()
+			continue
+		}
+
+		rc.dead = true
+		err := j.reconnect(ctx)
+		j.mu.Unlock()
+
+		if err == nil {
+			rc.reconnected(j)
+		}
+	}
+}
+
+// sendKeepAlive sends a keepalive request on the SSH connection, and waits up to timeout
+// for the reply.
+func sendKeepAlive(client *ssh.Client, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return errors.New("keepalive timed out")
+	}
+}
@@ -0,0 +1,131 @@
+package junos_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scottdware/go-junos"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestReconnect(t *testing.T) {
+	j, srv := newSession(t)
+
+	reconnects := make(chan *junos.Junos, 1)
+	err := j.EnableReconnect(&junos.ReconnectOptions{
+		MinBackoff:  10 * time.Millisecond,
+		OnReconnect: func(j *junos.Junos) { reconnects <- j },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := j.EnableReconnect(nil); err == nil {
+		t.Error("EnableReconnect succeeded on a session it's already enabled for")
+	}
+
+	if err := j.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.CloseClientConnections()
+
+	// The RPC in flight (or the first one after) when the transport fails returns an
+	// error, and the next one re-establishes the session.
+	if err := j.Ping(); err == nil {
+		if err := j.Ping(); err == nil {
+			t.Fatal("Ping succeeded on a dropped connection without reconnecting")
+		}
+	}
+
+	if err := j.Ping(); err != nil {
+		t.Fatalf("Ping after the connection dropped returned %v", err)
+	}
+
+	select {
+	case nj := <-reconnects:
+		if nj != j {
+			t.Error("OnReconnect wasn't called with the session")
+		}
+	default:
+		t.Error("OnReconnect wasn't called")
+	}
+
+	if srv.Sessions() < 2 {
+		t.Errorf("the server has seen %d sessions, want at least 2", srv.Sessions())
+	}
+
+	if j.Hostname == "" {
+		t.Error("the facts weren't gathered again after reconnecting")
+	}
+}
+
+func TestReconnectKeepAlive(t *testing.T) {
+	j, srv := newSession(t)
+
+	reconnects := make(chan struct{}, 1)
+	err := j.EnableReconnect(&junos.ReconnectOptions{
+		KeepAlive:   20 * time.Millisecond,
+		MinBackoff:  10 * time.Millisecond,
+		OnReconnect: func(*junos.Junos) { reconnects <- struct{}{} },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.CloseClientConnections()
+
+	select {
+	case <-reconnects:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the session wasn't re-established after a keepalive failed")
+	}
+
+	if err := j.Ping(); err != nil {
+		t.Fatalf("Ping after reconnecting returned %v", err)
+	}
+}
+
+func TestReconnectGivesUp(t *testing.T) {
+	j, srv := newSession(t)
+
+	err := j.EnableReconnect(&junos.ReconnectOptions{MaxAttempts: 2, MinBackoff: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Close()
+	j.Ping()
+
+	err = j.Ping()
+	if err == nil || !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Errorf("Ping after the device went away returned %v", err)
+	}
+}
+
+func TestReconnectUnsupported(t *testing.T) {
+	srv := newServer(t)
+
+	nc, err := net.Dial("tcp", srv.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &ssh.ClientConfig{
+		User:            srv.Username,
+		Auth:            []ssh.AuthMethod{ssh.Password(srv.Password)},
+		HostKeyCallback: ssh.FixedHostKey(srv.HostKey()),
+	}
+
+	j, err := junos.NewSessionFromNetConn(srv.Addr, nc, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	if err := j.EnableReconnect(nil); err == nil {
+		t.Error("EnableReconnect succeeded on a session created from a net.Conn")
+	}
+}
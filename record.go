@@ -0,0 +1,314 @@
+package junos
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/Juniper/go-netconf/netconf"
+)
+
+// ReplayMode controls how a replayed session picks the reply to each RPC.
+type ReplayMode int
+
+const (
+	// ReplayInOrder serves the recorded replies in the order they were recorded, and fails
+	// any RPC that doesn't match the recorded request at that point.
+	ReplayInOrder ReplayMode = iota
+
+	// ReplayMatch serves the reply recorded for the same request, regardless of the order
+	// the RPCs are run in. If a request was recorded more than once, the replies are served
+	// in order, with the last one being reused once they've all been served.
+	ReplayMatch
+)
+
+// recording is where a session's RPCs are being recorded to. It's shared by every transport
+// the session uses, so the recording carries on after a reconnect.
+type recording struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// recorder is a transport that records each request and reply on the underlying transport.
+type recorder struct {
+	netconf.Transport
+	rec *recording
+	req []byte
+}
+
+// replayTransport is a transport that serves the replies from a recording.
+type replayTransport struct {
+	mode    ReplayMode
+	hello   []byte
+	pairs   []replayPair
+	next    int
+	pending []byte
+	closed  bool
+	mu      sync.Mutex
+}
+
+// replayPair is a recorded request, and the reply to it.
+type replayPair struct {
+	request string
+	reply   []byte
+	served  bool
+}
+
+// Record writes every RPC run on the session, along with the device's reply, to w (usually a
+// file). The recording can be replayed with NewSessionFromRecording, to test code against
+// real-world output without the device. Recording starts with the device's hello message,
+// followed by the facts, which are gathered again so they're part of the recording.
+//
+// The recording uses the NETCONF 1.0 framing, with each message followed by "]]>]]>", and
+// the message-id dropped from each request, so it can be reviewed and edited by hand.
+func (j *Junos) Record(w io.Writer) error {
+	rec := &recording{w: w}
+
+	j.mu.Lock()
+	j.connMu.Lock()
+	s := j.Session
+
+	hello, err := xml.Marshal(&netconf.HelloMessage{
+		Capabilities: s.ServerCapabilities,
+		SessionID:    s.SessionID,
+	})
+	if err == nil {
+		err = rec.write(hello)
+	}
+
+	if err == nil {
+		s.Transport = rec.wrap(s.Transport)
+		j.recording = rec
+	}
+	j.connMu.Unlock()
+	j.mu.Unlock()
+
+	if err != nil {
+		return fmt.Errorf("error writing recording - %s", err)
+	}
+
+	return j.GatherFacts()
+}
+
+// NewSessionFromRecording returns a session that replays a recording made with Record, in
+// place of a device. The facts are gathered from the recording, the same as they would be
+// from the device, so the options (see SessionOption) must match the ones the recorded
+// session was established with; e.g. GatherMinimalFacts, if it only gathered the minimal
+// facts.
+func NewSessionFromRecording(r io.Reader, mode ReplayMode, options ...SessionOption) (*Junos, error) {
+	t, err := NewReplayTransport(r, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	return newSessionFromNetconf(context.Background(), netconf.NewSession(t), newSessionConfig(options))
+}
+
+// NewReplayTransport returns a NETCONF transport that replays a recording made with Record,
+// for use with netconf.NewSession.
+func NewReplayTransport(r io.Reader, mode ReplayMode) (netconf.Transport, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading recording - %s", err)
+	}
+
+	var msgs [][]byte
+	for _, m := range bytes.Split(data, []byte(msgSeparator)) {
+		if m = bytes.TrimSpace(m); len(m) > 0 {
+			msgs = append(msgs, m)
+		}
+	}
+
+	if len(msgs) == 0 || !bytes.Contains(msgs[0], []byte("<hello")) {
+		return nil, errors.New("invalid recording - it must start with the device's hello message")
+	}
+
+	if len(msgs)%2 != 1 {
+		return nil, errors.New("invalid recording - the last request has no reply")
+	}
+
+	t := &replayTransport{
+		mode:  mode,
+		hello: msgs[0],
+	}
+
+	for i := 1; i < len(msgs); i += 2 {
+		req, err := rpcBody(msgs[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid recording - request %d: %s", len(t.pairs)+1, err)
+		}
+
+		t.pairs = append(t.pairs, replayPair{request: req, reply: msgs[i+1]})
+	}
+
+	return t, nil
+}
+
+// unwrapTransport returns the transport underneath any recorder.
+func unwrapTransport(t netconf.Transport) netconf.Transport {
+	if r, ok := t.(*recorder); ok {
+		return r.Transport
+	}
+
+	return t
+}
+
+// wrap returns a transport that records to rec.
+func (rec *recording) wrap(t netconf.Transport) netconf.Transport {
+	return &recorder{Transport: unwrapTransport(t), rec: rec}
+}
+
+// write writes a message to the recording.
+func (rec *recording) write(msgs ...[]byte) error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, m := range msgs {
+		buf.Write(m)
+		buf.WriteString("\n" + msgSeparator + "\n")
+	}
+
+	_, err := rec.w.Write(buf.Bytes())
+
+	return err
+}
+
+// Send sends the request, and holds on to it until the reply is received.
+func (r *recorder) Send(data []byte) error {
+	r.req = data
+
+	return r.Transport.Send(data)
+}
+
+// Receive receives the reply, and records it along with the request.
+func (r *recorder) Receive() ([]byte, error) {
+	reply, err := r.Transport.Receive()
+	if err != nil || r.req == nil {
+		return reply, err
+	}
+
+	req, err := rpcBody(r.req)
+	r.req = nil
+	if err != nil {
+		return reply, nil
+	}
+
+	if err := r.rec.write([]byte("<rpc>"+req+"</rpc>"), reply); err != nil {
+		return nil, fmt.Errorf("error writing recording - %s", err)
+	}
+
+	return reply, nil
+}
+
+// Send finds the recorded reply to the request.
+func (t *replayTransport) Send(data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return errors.New("replay transport is closed")
+	}
+
+	req, err := rpcBody(data)
+	if err != nil {
+		return err
+	}
+
+	switch t.mode {
+	case ReplayMatch:
+		last := -1
+		for i := range t.pairs {
+			if t.pairs[i].request != req {
+				continue
+			}
+
+			last = i
+			if !t.pairs[i].served {
+				break
+			}
+		}
+
+		if last < 0 {
+			return fmt.Errorf("no reply recorded for %s", req)
+		}
+
+		t.pairs[last].served = true
+		t.pending = t.pairs[last].reply
+	default:
+		if t.next >= len(t.pairs) {
+			return fmt.Errorf("no more replies recorded (request %d is %s)", t.next+1, req)
+		}
+
+		p := &t.pairs[t.next]
+		if p.request != req {
+			return fmt.Errorf("request %d doesn't match the recording - got %s, recorded %s", t.next+1, req, p.request)
+		}
+
+		t.next++
+		p.served = true
+		t.pending = p.reply
+	}
+
+	return nil
+}
+
+// Receive returns the reply found by the last Send.
+func (t *replayTransport) Receive() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending == nil {
+		return nil, errors.New("no request has been sent")
+	}
+
+	reply := t.pending
+	t.pending = nil
+
+	return reply, nil
+}
+
+// SendHello does nothing, since there's no device to send it to.
+func (t *replayTransport) SendHello(hello *netconf.HelloMessage) error {
+	return nil
+}
+
+// ReceiveHello returns the device's recorded hello message.
+func (t *replayTransport) ReceiveHello() (*netconf.HelloMessage, error) {
+	hello := new(netconf.HelloMessage)
+	if err := xml.Unmarshal(t.hello, hello); err != nil {
+		return hello, fmt.Errorf("invalid hello message - %s", err)
+	}
+
+	return hello, nil
+}
+
+// Close stops the transport from serving any more replies.
+func (t *replayTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+
+	return nil
+}
+
+// rpcBody returns the contents of the <rpc> element in a request.
+func rpcBody(data []byte) (string, error) {
+	var rpc struct {
+		XMLName xml.Name `xml:"rpc"`
+		Inner   []byte   `xml:",innerxml"`
+	}
+
+	if err := xml.Unmarshal(data, &rpc); err != nil {
+		return "", fmt.Errorf("invalid request - %s", err)
+	}
+
+	return strings.TrimSpace(string(rpc.Inner)), nil
+}
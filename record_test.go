@@ -0,0 +1,168 @@
+package junos_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+// record runs a few RPCs against a test server, including a commit that succeeds, and one
+// that fails, and returns the recording.
+func record(t *testing.T) []byte {
+	t.Helper()
+
+	j, srv := newSession(t)
+
+	var buf bytes.Buffer
+	if err := j.Record(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := j.View("arp"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := j.GetConfig("text"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := j.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Handle("commit-configuration", junostest.CommitError("[edit]", "system", "commit failed"))
+	if err := j.Commit(); err == nil {
+		t.Fatal("the failing commit succeeded")
+	}
+
+	return buf.Bytes()
+}
+
+func TestRecord(t *testing.T) {
+	rec := string(record(t))
+
+	if !strings.HasPrefix(rec, "<hello") {
+		t.Errorf("the recording doesn't start with the hello: %.60q", rec)
+	}
+
+	if strings.Contains(rec, "<rpc message-id") {
+		t.Error("the recording includes the requests' message-ids")
+	}
+
+	for _, rpc := range []string{"get-software-information", "get-arp-table-information", "commit-configuration"} {
+		if !strings.Contains(rec, "<"+rpc) {
+			t.Errorf("the recording doesn't include %s", rpc)
+		}
+	}
+}
+
+func TestReplayInOrder(t *testing.T) {
+	r, err := junos.NewSessionFromRecording(bytes.NewReader(record(t)), junos.ReplayInOrder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if r.Hostname != "fw1" || len(r.Session.ServerCapabilities) == 0 {
+		t.Errorf("got hostname %q and capabilities %v from the recording", r.Hostname, r.Session.ServerCapabilities)
+	}
+
+	v, err := r.View("arp")
+	if err != nil || v.Arp.Count != 1 {
+		t.Fatalf("View returned %+v, %v", v, err)
+	}
+
+	if _, err := r.GetConfig("text"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Commit(); err == nil || !strings.Contains(err.Error(), "commit failed") {
+		t.Errorf("the second commit returned %v", err)
+	}
+
+	if err := r.Commit(); err == nil {
+		t.Error("an RPC after the end of the recording succeeded")
+	}
+}
+
+func TestReplayInOrderMismatch(t *testing.T) {
+	r, err := junos.NewSessionFromRecording(bytes.NewReader(record(t)), junos.ReplayInOrder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if err := r.Commit(); err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Errorf("an RPC out of order returned %v", err)
+	}
+}
+
+func TestReplayMatch(t *testing.T) {
+	r, err := junos.NewSessionFromRecording(bytes.NewReader(record(t)), junos.ReplayMatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if err := r.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := r.Commit(); err == nil {
+			t.Errorf("commit %d didn't return the last recorded reply", i+2)
+		}
+	}
+
+	config, err := r.GetConfig("text")
+	if err != nil || !strings.Contains(config, "host-name fw1") {
+		t.Errorf("GetConfig returned %q, %v", config, err)
+	}
+
+	if _, err := r.View("route"); err == nil || !strings.Contains(err.Error(), "no reply recorded") {
+		t.Errorf("an RPC that wasn't recorded returned %v", err)
+	}
+}
+
+func TestReplayMinimalFacts(t *testing.T) {
+	srv := newServer(t)
+
+	auth := srv.Auth()
+	auth.MinimalFacts = true
+
+	j, err := junos.NewSession(srv.Addr, auth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	var buf bytes.Buffer
+	if err := j.Record(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := j.View("arp"); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := junos.NewSessionFromRecording(bytes.NewReader(buf.Bytes()), junos.ReplayInOrder, junos.GatherMinimalFacts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if !r.MinimalFacts || r.Hostname != "fw1" {
+		t.Errorf("got MinimalFacts %v and hostname %q from the recording", r.MinimalFacts, r.Hostname)
+	}
+
+	if _, err := r.View("arp"); err != nil {
+		t.Errorf("View returned %v", err)
+	}
+}
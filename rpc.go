@@ -0,0 +1,212 @@
+package junos
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/Juniper/go-netconf/netconf"
+)
+
+// RPCOption changes how RPC handles the reply.
+type RPCOption int
+
+const (
+	// StripMultiRE unwraps the reply from each routing engine on devices with more than one
+	// (e.g. chassis clusters or dual-RE routers), where the reply is wrapped in a
+	// <multi-routing-engine-results> element. If response points to a slice, the reply from
+	// each routing engine is appended to it, otherwise the reply from the first one is used.
+	StripMultiRE RPCOption = iota + 1
+)
+
+// RPC runs an RPC on the device, and unmarshals the reply into response.
+//
+// The request is either the RPC's XML, as a string or []byte (e.g. "<get-software-information/>"),
+// or a value that encoding/xml marshals into it, e.g.:
+//
+//	type getRouteInformation struct {
+//		XMLName     xml.Name `xml:"get-route-information"`
+//		Destination string   `xml:"destination,omitempty"`
+//	}
+//
+// The response is a pointer to a value that encoding/xml unmarshals the reply into, or a
+// *string, which is set to the reply's XML. It can be nil, if you only care whether the RPC
+// succeeded. If the device reports an error, an *RPCError is returned. For RPCs run with
+// format="json", the reply is decoded using DecodeJSON instead (or the JSON itself is
+// returned, for a *string).
+func (j *Junos) RPC(request, response interface{}, options ...RPCOption) error {
+	return j.RPCContext(context.Background(), request, response, options...)
+}
+
+// RPCContext is the same as RPC, but stops waiting on the device once the given context
+// is done.
+func (j *Junos) RPCContext(ctx context.Context, request, response interface{}, options ...RPCOption) error {
+	command, err := marshalRPC(request)
+	if err != nil {
+		return err
+	}
+
+	var reply *netconf.RPCReply
+	err = j.retry(ctx, func() error {
+		reply, err = j.exec(ctx, command)
+		if err != nil {
+			return err
+		}
+
+		return j.replyError(reply)
+	})
+	if err != nil {
+		return err
+	}
+
+	if response == nil {
+		return nil
+	}
+
+	// RPCs run with format="json" reply with JSON, rather than XML.
+	if isJSON(reply.Data) {
+		data, err := jsonReply(reply.Data)
+		if err != nil {
+			return err
+		}
+
+		if s, ok := response.(*string); ok {
+			*s = data
+			return nil
+		}
+
+		return DecodeJSON([]byte(data), response)
+	}
+
+	data := []string{reply.Data}
+	for _, o := range options {
+		if o == StripMultiRE {
+			if payloads, ok := multiREPayloads(reply.Data); ok {
+				data = payloads
+			}
+		}
+	}
+
+	return unmarshalRPC(data, response)
+}
+
+// marshalRPC returns the XML for an RPC request.
+func marshalRPC(request interface{}) (string, error) {
+	var command string
+
+	switch r := request.(type) {
+	case string:
+		command = r
+	case []byte:
+		command = string(r)
+	default:
+		data, err := xml.Marshal(request)
+		if err != nil {
+			return "", fmt.Errorf("error marshalling RPC request - %s", err)
+		}
+
+		command = string(data)
+	}
+
+	if strings.TrimSpace(command) == "" {
+		return "", errors.New("an RPC request must not be empty")
+	}
+
+	return command, nil
+}
+
+// unmarshalRPC unmarshals each reply into response. If there's more than one, response must
+// point to a slice, otherwise only the first one is used.
+func unmarshalRPC(data []string, response interface{}) error {
+	if s, ok := response.(*string); ok {
+		*s = strings.Join(data, "\n")
+		return nil
+	}
+
+	v := reflect.ValueOf(response)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("an RPC response must be a non-nil pointer")
+	}
+
+	if len(data) > 1 && v.Elem().Kind() == reflect.Slice {
+		slice := v.Elem()
+		for _, d := range data {
+			item := reflect.New(slice.Type().Elem())
+			if err := xml.Unmarshal([]byte(d), item.Interface()); err != nil {
+				return fmt.Errorf("error unmarshalling RPC reply - %s", err)
+			}
+
+			slice.Set(reflect.Append(slice, item.Elem()))
+		}
+
+		return nil
+	}
+
+	if len(data) == 0 || strings.TrimSpace(data[0]) == "" {
+		return nil
+	}
+
+	if err := xml.Unmarshal([]byte(data[0]), response); err != nil {
+		return fmt.Errorf("error unmarshalling RPC reply - %s", err)
+	}
+
+	return nil
+}
+
+// multiREPayloads returns the reply from each routing engine, if the reply is wrapped in a
+// <multi-routing-engine-results> element.
+func multiREPayloads(data string) ([]string, bool) {
+	raw := []byte(data)
+	d := xml.NewDecoder(bytes.NewReader(raw))
+
+	var payloads []string
+	depth := 0
+	found := false
+
+	for {
+		start := d.InputOffset()
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+
+			switch {
+			case depth == 1 && t.Name.Local == "multi-routing-engine-results":
+				found = true
+			case depth == 1:
+				return nil, false
+			case depth == 3 && t.Name.Local != "re-name":
+				if err := d.Skip(); err != nil {
+					return nil, false
+				}
+				depth--
+
+				payloads = append(payloads, string(raw[start:d.InputOffset()]))
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+
+	return payloads, found
+}
+
+// xmlEscape escapes s for use as the text of an XML element.
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+
+	return buf.String()
+}
@@ -0,0 +1,94 @@
+package junos_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+type softwareInformation struct {
+	XMLName  xml.Name `xml:"software-information"`
+	Hostname string   `xml:"host-name"`
+	Model    string   `xml:"product-model"`
+}
+
+type getRouteInformation struct {
+	XMLName     xml.Name `xml:"get-route-information"`
+	Destination string   `xml:"destination,omitempty"`
+}
+
+func TestRPC(t *testing.T) {
+	j, _ := newSession(t)
+
+	var sw softwareInformation
+	if err := j.RPC("<get-software-information/>", &sw); err != nil {
+		t.Fatal(err)
+	}
+
+	if sw.Hostname != "fw1" || sw.Model == "" {
+		t.Errorf("got %+v", sw)
+	}
+}
+
+func TestRPCMarshalRequest(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Reset()
+
+	var reply string
+	if err := j.RPC(getRouteInformation{Destination: "0/0"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(reply, "<table-name>inet.0</table-name>") {
+		t.Errorf("got reply %q", reply)
+	}
+
+	want := "<get-route-information><destination>0/0</destination></get-route-information>"
+	if reqs := srv.Requests(); len(reqs) != 1 || reqs[0].XML != want {
+		t.Errorf("got requests %+v, want %s", reqs, want)
+	}
+}
+
+func TestRPCStripMultiRE(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Handle("get-software-information", junostest.SoftwareInformationMultiRE)
+
+	var all []softwareInformation
+	if err := j.RPC("<get-software-information/>", &all, junos.StripMultiRE); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(all) != 2 || all[0].Hostname != "fw1-node0" || all[1].Hostname != "fw1-node1" {
+		t.Errorf("got %+v", all)
+	}
+
+	var first softwareInformation
+	if err := j.RPC("<get-software-information/>", &first, junos.StripMultiRE); err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Hostname != "fw1-node0" {
+		t.Errorf("got %+v", first)
+	}
+}
+
+func TestRPCInvalid(t *testing.T) {
+	j, _ := newSession(t)
+
+	var rpcErr *junos.RPCError
+	if err := j.RPC("<get-nothing-at-all/>", nil); !errors.As(err, &rpcErr) {
+		t.Errorf("an unknown RPC returned %#v", err)
+	}
+
+	if err := j.RPC("", nil); err == nil {
+		t.Error("an empty RPC succeeded")
+	}
+
+	if err := j.RPC("<get-software-information/>", softwareInformation{}); err == nil {
+		t.Error("an RPC succeeded with a response that isn't a pointer")
+	}
+}
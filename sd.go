@@ -1,6 +1,7 @@
 package junos
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -309,11 +310,11 @@ var modifyVariableXML = `
 `
 
 // getDeviceID returns the ID of a managed device.
-func (s *Space) getSDDeviceID(device interface{}) (int, error) {
+func (s *Space) getSDDeviceID(ctx context.Context, device interface{}) (int, error) {
 	var err error
 	var deviceID int
 	ipRegex := regexp.MustCompile(`(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3})`)
-	devices, err := s.SecurityDevices()
+	devices, err := s.SecurityDevicesContext(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -340,15 +341,15 @@ func (s *Space) getSDDeviceID(device interface{}) (int, error) {
 }
 
 // getObjectID returns the ID of the address or service object.
-func (s *Space) getObjectID(object interface{}, otype string) (int, error) {
+func (s *Space) getObjectID(ctx context.Context, object interface{}, otype string) (int, error) {
 	var err error
 	var objectID int
 	var services *Services
 	ipRegex := regexp.MustCompile(`(\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}\/\d+)`)
 	if otype == "service" {
-		services, err = s.Services(object.(string))
+		services, err = s.ServicesContext(ctx, object.(string))
 	}
-	objects, err := s.Addresses(object.(string))
+	objects, err := s.AddressesContext(ctx, object.(string))
 	if err != nil {
 		return 0, err
 	}
@@ -382,10 +383,10 @@ func (s *Space) getObjectID(object interface{}, otype string) (int, error) {
 }
 
 // getPolicyID returns the ID of a firewall policy.
-func (s *Space) getPolicyID(object string) (int, error) {
+func (s *Space) getPolicyID(ctx context.Context, object string) (int, error) {
 	var err error
 	var objectID int
-	objects, err := s.Policies()
+	objects, err := s.PoliciesContext(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -400,10 +401,10 @@ func (s *Space) getPolicyID(object string) (int, error) {
 }
 
 // getVariableID returns the ID of a polymorphic (variable) object.
-func (s *Space) getVariableID(variable string) (int, error) {
+func (s *Space) getVariableID(ctx context.Context, variable string) (int, error) {
 	var err error
 	var variableID int
-	vars, err := s.Variables()
+	vars, err := s.VariablesContext(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -460,6 +461,12 @@ func (s *Space) modifyVariableContent(data *existingVariable, moid, firewall, ad
 // about each address that is managed by Space. Filter is optional, but if specified
 // can help reduce the amount of objects returned.
 func (s *Space) Addresses(filter ...string) (*Addresses, error) {
+	return s.AddressesContext(context.Background(), filter...)
+}
+
+// AddressesContext is the same as Addresses, but cancels the request to the server once
+// the given context is done.
+func (s *Space) AddressesContext(ctx context.Context, filter ...string) (*Addresses, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	var addresses Addresses
@@ -473,7 +480,7 @@ func (s *Space) Addresses(filter ...string) (*Addresses, error) {
 	}
 
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/address-management/addresses", s.Host)
-	resp := r.Send("get", uri, nil, nil, query)
+	resp := s.send(ctx, r, "get", uri, nil, nil, query)
 	if resp.Error != nil {
 		return nil, resp.Error
 	}
@@ -488,6 +495,12 @@ func (s *Space) Addresses(filter ...string) (*Addresses, error) {
 
 // AddAddress creates a new address object in Junos Space. Description is optional.
 func (s *Space) AddAddress(name, ip string, description ...string) error {
+	return s.AddAddressContext(context.Background(), name, ip, description...)
+}
+
+// AddAddressContext is the same as AddAddress, but cancels the request to the server once
+// the given context is done.
+func (s *Space) AddAddressContext(ctx context.Context, name, ip string, description ...string) error {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{}
@@ -508,7 +521,7 @@ func (s *Space) AddAddress(name, ip string, description ...string) error {
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/address-management/addresses", s.Host)
 	headers["Content-Type"] = contentAddress
 
-	resp := r.Send("post", uri, []byte(address), headers, nil)
+	resp := s.send(ctx, r, "post", uri, []byte(address), headers, nil)
 	if resp.Error != nil {
 		return resp.Error
 	}
@@ -518,6 +531,12 @@ func (s *Space) AddAddress(name, ip string, description ...string) error {
 
 // EditAddress changes the IP/Network/FQDN of the given address object name.
 func (s *Space) EditAddress(name, newip string) error {
+	return s.EditAddressContext(context.Background(), name, newip)
+}
+
+// EditAddressContext is the same as EditAddress, but cancels the request to the server
+// once the given context is done.
+func (s *Space) EditAddressContext(ctx context.Context, name, newip string) error {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{}
@@ -525,7 +544,7 @@ func (s *Space) EditAddress(name, newip string) error {
 	addrInfo := s.getAddrTypeIP(newip)
 	re := regexp.MustCompile(`[-\w\.]*\.(com|net|org|us|gov)$`)
 
-	objectID, err := s.getObjectID(name, "address")
+	objectID, err := s.getObjectID(ctx, name, "address")
 	if err != nil {
 		return err
 	}
@@ -533,7 +552,7 @@ func (s *Space) EditAddress(name, newip string) error {
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/address-management/addresses/%d", s.Host, objectID)
 	headers["Content-Type"] = contentAddress
 
-	exResp := r.Send("get", uri, nil, headers, nil)
+	exResp := s.send(ctx, r, "get", uri, nil, headers, nil)
 	if exResp.Error != nil {
 		return exResp.Error
 	}
@@ -549,7 +568,7 @@ func (s *Space) EditAddress(name, newip string) error {
 		updateContent = fmt.Sprintf(modifyDNSXML, existing.Name, addrInfo[0], existing.EditVersion, addrInfo[1], existing.Description)
 	}
 
-	resp := r.Send("put", uri, []byte(updateContent), headers, nil)
+	resp := s.send(ctx, r, "put", uri, []byte(updateContent), headers, nil)
 	if resp.Error != nil {
 		return resp.Error
 	}
@@ -560,6 +579,12 @@ func (s *Space) EditAddress(name, newip string) error {
 // AddService creates a new service object to Junos Space. For a single port, just enter in
 // the number. For a range of ports, enter the low-high range in quotes like so: "10000-10002".
 func (s *Space) AddService(protocol, name string, ports interface{}, description string, timeout int) error {
+	return s.AddServiceContext(context.Background(), protocol, name, ports, description, timeout)
+}
+
+// AddServiceContext is the same as AddService, but cancels the request to the server once
+// the given context is done.
+func (s *Space) AddServiceContext(ctx context.Context, protocol, name string, ports interface{}, description string, timeout int) error {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{}
@@ -592,7 +617,7 @@ func (s *Space) AddService(protocol, name string, ports interface{}, description
 	headers["Content-Type"] = contentService
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/service-management/services", s.Host)
 
-	resp := r.Send("post", uri, []byte(service), headers, nil)
+	resp := s.send(ctx, r, "post", uri, []byte(service), headers, nil)
 	if resp.Error != nil {
 		return resp.Error
 	}
@@ -602,6 +627,12 @@ func (s *Space) AddService(protocol, name string, ports interface{}, description
 
 // AddGroup creates a new address or service group in Junos Space. Objecttype must be "address" or "service".
 func (s *Space) AddGroup(grouptype, name string, description ...string) error {
+	return s.AddGroupContext(context.Background(), grouptype, name, description...)
+}
+
+// AddGroupContext is the same as AddGroup, but cancels the request to the server once the
+// given context is done.
+func (s *Space) AddGroupContext(ctx context.Context, grouptype, name string, description ...string) error {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{}
@@ -623,7 +654,7 @@ func (s *Space) AddGroup(grouptype, name string, description ...string) error {
 	groupXML := fmt.Sprintf(addGroupXML, name, desc)
 	headers["Content-Type"] = content
 
-	resp := r.Send("post", uri, []byte(groupXML), headers, nil)
+	resp := s.send(ctx, r, "post", uri, []byte(groupXML), headers, nil)
 	if resp.Error != nil {
 		return resp.Error
 	}
@@ -634,12 +665,18 @@ func (s *Space) AddGroup(grouptype, name string, description ...string) error {
 // EditGroup adds or removes objects to/from an existing address or service group. Grouptype must be
 // "address" or "service." Action must be add or remove.
 func (s *Space) EditGroup(grouptype, action, object, name string) error {
+	return s.EditGroupContext(context.Background(), grouptype, action, object, name)
+}
+
+// EditGroupContext is the same as EditGroup, but cancels the request to the server once
+// the given context is done.
+func (s *Space) EditGroupContext(ctx context.Context, grouptype, action, object, name string) error {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{}
 	var err error
 	var uri, content, rel, xmlBody string
-	objectID, err := s.getObjectID(name, grouptype)
+	objectID, err := s.getObjectID(ctx, name, grouptype)
 	if err != nil {
 		return err
 	}
@@ -664,7 +701,7 @@ func (s *Space) EditGroup(grouptype, action, object, name string) error {
 			headers["Content-Type"] = content
 		}
 
-		resp := r.Send("patch", uri, []byte(xmlBody), headers, nil)
+		resp := s.send(ctx, r, "patch", uri, []byte(xmlBody), headers, nil)
 		if resp.Error != nil {
 			return resp.Error
 		}
@@ -676,12 +713,18 @@ func (s *Space) EditGroup(grouptype, action, object, name string) error {
 // RenameObject renames an address or service object to the given new name. Grouptype
 // must be "address" or "service"
 func (s *Space) RenameObject(grouptype, name, newname string) error {
+	return s.RenameObjectContext(context.Background(), grouptype, name, newname)
+}
+
+// RenameObjectContext is the same as RenameObject, but cancels the request to the server
+// once the given context is done.
+func (s *Space) RenameObjectContext(ctx context.Context, grouptype, name, newname string) error {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{}
 	var err error
 	var uri, content, rel, xmlBody string
-	objectID, err := s.getObjectID(name, grouptype)
+	objectID, err := s.getObjectID(ctx, name, grouptype)
 	if err != nil {
 		return err
 	}
@@ -700,7 +743,7 @@ func (s *Space) RenameObject(grouptype, name, newname string) error {
 		xmlBody = fmt.Sprintf(renameXML, rel, newname)
 		headers["Content-Type"] = content
 
-		resp := r.Send("patch", uri, []byte(xmlBody), headers, nil)
+		resp := s.send(ctx, r, "patch", uri, []byte(xmlBody), headers, nil)
 		if resp.Error != nil {
 			return resp.Error
 		}
@@ -712,11 +755,17 @@ func (s *Space) RenameObject(grouptype, name, newname string) error {
 // DeleteObject removes an address or service object from Junos Space. Grouptype
 // must be "address" or "service"
 func (s *Space) DeleteObject(grouptype, name string) error {
+	return s.DeleteObjectContext(context.Background(), grouptype, name)
+}
+
+// DeleteObjectContext is the same as DeleteObject, but cancels the request to the server
+// once the given context is done.
+func (s *Space) DeleteObjectContext(ctx context.Context, grouptype, name string) error {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	var err error
 	var uri string
-	objectID, err := s.getObjectID(name, grouptype)
+	objectID, err := s.getObjectID(ctx, name, grouptype)
 	if err != nil {
 		return err
 	}
@@ -728,7 +777,7 @@ func (s *Space) DeleteObject(grouptype, name string) error {
 			uri = fmt.Sprintf("https://%s/api/juniper/sd/service-management/services/%d", s.Host, objectID)
 		}
 
-		resp := r.Send("delete", uri, nil, nil, nil)
+		resp := s.send(ctx, r, "delete", uri, nil, nil, nil)
 		if resp.Error != nil {
 			return resp.Error
 		}
@@ -740,6 +789,12 @@ func (s *Space) DeleteObject(grouptype, name string) error {
 // Services queries the Junos Space server and returns all of the information
 // about each service that is managed by Space.
 func (s *Space) Services(filter ...string) (*Services, error) {
+	return s.ServicesContext(context.Background(), filter...)
+}
+
+// ServicesContext is the same as Services, but cancels the request to the server once the
+// given context is done.
+func (s *Space) ServicesContext(ctx context.Context, filter ...string) (*Services, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	var services Services
@@ -754,7 +809,7 @@ func (s *Space) Services(filter ...string) (*Services, error) {
 
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/service-management/services", s.Host)
 
-	resp := r.Send("get", uri, nil, nil, query)
+	resp := s.send(ctx, r, "get", uri, nil, nil, query)
 	if resp.Error != nil {
 		return nil, resp.Error
 	}
@@ -770,17 +825,23 @@ func (s *Space) Services(filter ...string) (*Services, error) {
 // GroupMembers lists all of the address or service objects within the
 // given group. Grouptype must be "address" or "service".
 func (s *Space) GroupMembers(grouptype, name string) (*GroupMembers, error) {
+	return s.GroupMembersContext(context.Background(), grouptype, name)
+}
+
+// GroupMembersContext is the same as GroupMembers, but cancels the request to the server
+// once the given context is done.
+func (s *Space) GroupMembersContext(ctx context.Context, grouptype, name string) (*GroupMembers, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	var members GroupMembers
-	objectID, err := s.getObjectID(name, grouptype)
+	objectID, err := s.getObjectID(ctx, name, grouptype)
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/address-management/addresses/%d", s.Host, objectID)
 
 	if grouptype == "service" {
 		uri = fmt.Sprintf("https://%s/api/juniper/sd/service-management/services/%d", s.Host, objectID)
 	}
 
-	resp := r.Send("get", uri, nil, nil, nil)
+	resp := s.send(ctx, r, "get", uri, nil, nil, nil)
 	if resp.Error != nil {
 		return nil, resp.Error
 	}
@@ -796,12 +857,18 @@ func (s *Space) GroupMembers(grouptype, name string) (*GroupMembers, error) {
 // SecurityDevices queries the Junos Space server and returns all of the information
 // about each security device that is managed by Space.
 func (s *Space) SecurityDevices() (*SecurityDevices, error) {
+	return s.SecurityDevicesContext(context.Background())
+}
+
+// SecurityDevicesContext is the same as SecurityDevices, but cancels the request to the
+// server once the given context is done.
+func (s *Space) SecurityDevicesContext(ctx context.Context) (*SecurityDevices, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	var devices SecurityDevices
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/device-management/devices", s.Host)
 
-	resp := r.Send("get", uri, nil, nil, nil)
+	resp := s.send(ctx, r, "get", uri, nil, nil, nil)
 	if resp.Error != nil {
 		return nil, resp.Error
 	}
@@ -816,12 +883,18 @@ func (s *Space) SecurityDevices() (*SecurityDevices, error) {
 
 // Policies returns a list of all firewall policies managed by Junos Space.
 func (s *Space) Policies() (*Policies, error) {
+	return s.PoliciesContext(context.Background())
+}
+
+// PoliciesContext is the same as Policies, but cancels the request to the server once the
+// given context is done.
+func (s *Space) PoliciesContext(ctx context.Context) (*Policies, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	var policies Policies
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/fwpolicy-management/firewall-policies", s.Host)
 
-	resp := r.Send("get", uri, nil, nil, nil)
+	resp := s.send(ctx, r, "get", uri, nil, nil, nil)
 	if resp.Error != nil {
 		return nil, resp.Error
 	}
@@ -837,6 +910,12 @@ func (s *Space) Policies() (*Policies, error) {
 // PublishPolicy publishes a changed firewall policy. If "true" is specified for
 // update, then Junos Space will also update the device.
 func (s *Space) PublishPolicy(policy interface{}, update bool) (int, error) {
+	return s.PublishPolicyContext(context.Background(), policy, update)
+}
+
+// PublishPolicyContext is the same as PublishPolicy, but cancels the request to the
+// server once the given context is done.
+func (s *Space) PublishPolicyContext(ctx context.Context, policy interface{}, update bool) (int, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{
@@ -851,7 +930,7 @@ func (s *Space) PublishPolicy(policy interface{}, update bool) (int, error) {
 	case int:
 		id = policy.(int)
 	case string:
-		id, err = s.getPolicyID(policy.(string))
+		id, err = s.getPolicyID(ctx, policy.(string))
 		if err != nil {
 			return 0, err
 		}
@@ -865,7 +944,7 @@ func (s *Space) PublishPolicy(policy interface{}, update bool) (int, error) {
 		uri = fmt.Sprintf("https://%s/api/juniper/sd/fwpolicy-management/publish?update=true", s.Host)
 	}
 
-	resp := r.Send("post", uri, []byte(publish), headers, nil)
+	resp := s.send(ctx, r, "post", uri, []byte(publish), headers, nil)
 	if resp.Error != nil {
 		return 0, resp.Error
 	}
@@ -881,6 +960,12 @@ func (s *Space) PublishPolicy(policy interface{}, update bool) (int, error) {
 // UpdateDevice will update a changed security device, synchronizing it with
 // Junos Space.
 func (s *Space) UpdateDevice(device interface{}) (int, error) {
+	return s.UpdateDeviceContext(context.Background(), device)
+}
+
+// UpdateDeviceContext is the same as UpdateDevice, but cancels the request to the server
+// once the given context is done.
+func (s *Space) UpdateDeviceContext(ctx context.Context, device interface{}) (int, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{
@@ -888,14 +973,14 @@ func (s *Space) UpdateDevice(device interface{}) (int, error) {
 	}
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/device-management/update-devices", s.Host)
 	var job jobID
-	deviceID, err := s.getDeviceID(device)
+	deviceID, err := s.getDeviceID(ctx, device)
 	if err != nil {
 		return 0, err
 	}
 
 	update := fmt.Sprintf(updateDeviceXML, deviceID)
 
-	resp := r.Send("post", uri, []byte(update), headers, nil)
+	resp := s.send(ctx, r, "post", uri, []byte(update), headers, nil)
 	if resp.Error != nil {
 		return 0, resp.Error
 	}
@@ -910,12 +995,18 @@ func (s *Space) UpdateDevice(device interface{}) (int, error) {
 
 // Variables returns a listing of all polymorphic (variable) objects.
 func (s *Space) Variables() (*Variables, error) {
+	return s.VariablesContext(context.Background())
+}
+
+// VariablesContext is the same as Variables, but cancels the request to the server once
+// the given context is done.
+func (s *Space) VariablesContext(ctx context.Context) (*Variables, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	var vars Variables
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/variable-management/variable-definitions", s.Host)
 
-	resp := r.Send("get", uri, nil, nil, nil)
+	resp := s.send(ctx, r, "get", uri, nil, nil, nil)
 	if resp.Error != nil {
 		return nil, resp.Error
 	}
@@ -932,13 +1023,19 @@ func (s *Space) Variables() (*Variables, error) {
 // The address option is a default address object that will be used. This address object must
 // already exist on the server.
 func (s *Space) AddVariable(name, address string, description ...string) error {
+	return s.AddVariableContext(context.Background(), name, address, description...)
+}
+
+// AddVariableContext is the same as AddVariable, but cancels the request to the server
+// once the given context is done.
+func (s *Space) AddVariableContext(ctx context.Context, name, address string, description ...string) error {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{
 		"Content-Type": contentVariable,
 	}
 	desc := ""
-	objectID, err := s.getObjectID(address, "address")
+	objectID, err := s.getObjectID(ctx, address, "address")
 	if err != nil {
 		return err
 	}
@@ -950,7 +1047,7 @@ func (s *Space) AddVariable(name, address string, description ...string) error {
 	varBody := fmt.Sprintf(createVariableXML, name, "ADDRESS", desc, address, objectID)
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/variable-management/variable-definitions", s.Host)
 
-	resp := r.Send("post", uri, []byte(varBody), headers, nil)
+	resp := s.send(ctx, r, "post", uri, []byte(varBody), headers, nil)
 	if resp.Error != nil {
 		return resp.Error
 	}
@@ -962,19 +1059,25 @@ func (s *Space) AddVariable(name, address string, description ...string) error {
 // If the variable object is in use by a policy, then it will not be deleted
 // until you remove it from the policy.
 func (s *Space) DeleteVariable(name string) error {
+	return s.DeleteVariableContext(context.Background(), name)
+}
+
+// DeleteVariableContext is the same as DeleteVariable, but cancels the request to the
+// server once the given context is done.
+func (s *Space) DeleteVariableContext(ctx context.Context, name string) error {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{
 		"Content-Type": contentVariable,
 	}
-	varID, err := s.getVariableID(name)
+	varID, err := s.getVariableID(ctx, name)
 	if err != nil {
 		return err
 	}
 
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/variable-management/variable-definitions/%d", s.Host, varID)
 
-	resp := r.Send("delete", uri, nil, headers, nil)
+	resp := s.send(ctx, r, "delete", uri, nil, headers, nil)
 	if resp.Error != nil {
 		return resp.Error
 	}
@@ -987,7 +1090,13 @@ func (s *Space) DeleteVariable(name string) error {
 // security devices (SecurityDevices()) once, instead of call the function
 // each time we want to modify a variable.
 func (s *Space) EditVariable() (*VariableManagement, error) {
-	devices, err := s.SecurityDevices()
+	return s.EditVariableContext(context.Background())
+}
+
+// EditVariableContext is the same as EditVariable, but cancels the request to the server
+// once the given context is done.
+func (s *Space) EditVariableContext(ctx context.Context) (*VariableManagement, error) {
+	devices, err := s.SecurityDevicesContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -1003,6 +1112,12 @@ func (s *Space) EditVariable() (*VariableManagement, error) {
 // object you wish to add the object to. You also must specify the device (firewall) that you
 // want to associate the variable object to.
 func (v *VariableManagement) Add(address, name, firewall string) error {
+	return v.AddContext(context.Background(), address, name, firewall)
+}
+
+// AddContext is the same as Add, but cancels the request to the server once the given
+// context is done.
+func (v *VariableManagement) AddContext(ctx context.Context, address, name, firewall string) error {
 	r := rested.NewRequest()
 	r.BasicAuth(v.Space.User, v.Space.Password)
 	headers := map[string]string{
@@ -1011,7 +1126,7 @@ func (v *VariableManagement) Add(address, name, firewall string) error {
 	var varData existingVariable
 	var deviceID int
 
-	varID, err := v.Space.getVariableID(name)
+	varID, err := v.Space.getVariableID(ctx, name)
 	if err != nil {
 		return err
 	}
@@ -1023,13 +1138,13 @@ func (v *VariableManagement) Add(address, name, firewall string) error {
 	}
 	moid := fmt.Sprintf("net.juniper.jnap.sm.om.jpa.SecurityDeviceEntity:%d", deviceID)
 
-	vid, err := v.Space.getObjectID(address, "address")
+	vid, err := v.Space.getObjectID(ctx, address, "address")
 	if err != nil {
 		return err
 	}
 
 	uri := fmt.Sprintf("https://%s/api/juniper/sd/variable-management/variable-definitions/%d", v.Space.Host, varID)
-	existing := r.Send("get", uri, nil, nil, nil)
+	existing := v.Space.send(ctx, r, "get", uri, nil, nil, nil)
 	if existing.Error != nil {
 		return existing.Error
 	}
@@ -1042,7 +1157,7 @@ func (v *VariableManagement) Add(address, name, firewall string) error {
 	varContent := v.Space.modifyVariableContent(&varData, moid, firewall, address, vid)
 	modifyVariable := fmt.Sprintf(modifyVariableXML, varData.Name, varData.Type, varData.Description, varData.Version, varData.DefaultName, varData.DefaultValue, varContent)
 
-	resp := r.Send("put", uri, []byte(modifyVariable), headers, nil)
+	resp := v.Space.send(ctx, r, "put", uri, []byte(modifyVariable), headers, nil)
 	if resp.Error != nil {
 		return resp.Error
 	}
@@ -1,6 +1,7 @@
 package junos
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 
@@ -71,10 +72,10 @@ var stageXML = `
 `
 
 // getSoftwareID returns the ID of the software package.
-func (s *Space) getSoftwareID(image string) (int, error) {
+func (s *Space) getSoftwareID(ctx context.Context, image string) (int, error) {
 	var err error
 	var softwareID int
-	images, err := s.Software()
+	images, err := s.SoftwareContext(ctx)
 	if err != nil {
 		return 0, err
 	}
@@ -91,18 +92,24 @@ func (s *Space) getSoftwareID(image string) (int, error) {
 // DeploySoftware starts the upgrade process on the device, using the given image along
 // with the options specified.
 func (s *Space) DeploySoftware(device, image string, options *SoftwareUpgrade) (int, error) {
+	return s.DeploySoftwareContext(context.Background(), device, image, options)
+}
+
+// DeploySoftwareContext is the same as DeploySoftware, but cancels the request to the
+// server once the given context is done.
+func (s *Space) DeploySoftwareContext(ctx context.Context, device, image string, options *SoftwareUpgrade) (int, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{
 		"Content-Type": contentExecDeploy,
 	}
 	var job jobID
-	deviceID, _ := s.getDeviceID(device)
-	softwareID, _ := s.getSoftwareID(image)
+	deviceID, _ := s.getDeviceID(ctx, device)
+	softwareID, _ := s.getSoftwareID(ctx, image)
 	deploy := fmt.Sprintf(deployXML, deviceID, options.UseDownloaded, options.Validate, options.Reboot, options.RebootAfter, options.Cleanup, options.RemoveAfter)
 	uri := fmt.Sprintf("https://%s/api/space/software-management/packages/%d/exec-deploy", s.Host, softwareID)
 
-	resp := r.Send("post", uri, []byte(deploy), headers, nil)
+	resp := s.send(ctx, r, "post", uri, []byte(deploy), headers, nil)
 	if resp.Error != nil {
 		return 0, resp.Error
 	}
@@ -117,18 +124,24 @@ func (s *Space) DeploySoftware(device, image string, options *SoftwareUpgrade) (
 
 // RemoveStagedSoftware will delete the staged software image on the device.
 func (s *Space) RemoveStagedSoftware(device, image string) (int, error) {
+	return s.RemoveStagedSoftwareContext(context.Background(), device, image)
+}
+
+// RemoveStagedSoftwareContext is the same as RemoveStagedSoftware, but cancels the
+// request to the server once the given context is done.
+func (s *Space) RemoveStagedSoftwareContext(ctx context.Context, device, image string) (int, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{
 		"Content-Type": contentExecRemove,
 	}
 	var job jobID
-	deviceID, _ := s.getDeviceID(device)
-	softwareID, _ := s.getSoftwareID(image)
+	deviceID, _ := s.getDeviceID(ctx, device)
+	softwareID, _ := s.getSoftwareID(ctx, image)
 	remove := fmt.Sprintf(removeStagedXML, deviceID)
 	uri := fmt.Sprintf("https://%s/api/space/software-management/packages/%d/exec-remove", s.Host, softwareID)
 
-	resp := r.Send("post", uri, []byte(remove), headers, nil)
+	resp := s.send(ctx, r, "post", uri, []byte(remove), headers, nil)
 	if resp.Error != nil {
 		return 0, resp.Error
 	}
@@ -144,12 +157,18 @@ func (s *Space) RemoveStagedSoftware(device, image string) (int, error) {
 // Software queries the Junos Space server and returns all of the information
 // about each software image that Space manages.
 func (s *Space) Software() (*SoftwarePackages, error) {
+	return s.SoftwareContext(context.Background())
+}
+
+// SoftwareContext is the same as Software, but cancels the request to the server once the
+// given context is done.
+func (s *Space) SoftwareContext(ctx context.Context) (*SoftwarePackages, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	var software SoftwarePackages
 	uri := fmt.Sprintf("https://%s/api/space/software-management/packages", s.Host)
 
-	resp := r.Send("get", uri, nil, nil, nil)
+	resp := s.send(ctx, r, "get", uri, nil, nil, nil)
 	if resp.Error != nil {
 		return nil, resp.Error
 	}
@@ -165,18 +184,24 @@ func (s *Space) Software() (*SoftwarePackages, error) {
 // StageSoftware loads the given software image onto the device but does not
 // upgrade it. The package is placed in the /var/tmp directory.
 func (s *Space) StageSoftware(device, image string, cleanup bool) (int, error) {
+	return s.StageSoftwareContext(context.Background(), device, image, cleanup)
+}
+
+// StageSoftwareContext is the same as StageSoftware, but cancels the request to the
+// server once the given context is done.
+func (s *Space) StageSoftwareContext(ctx context.Context, device, image string, cleanup bool) (int, error) {
 	r := rested.NewRequest()
 	r.BasicAuth(s.User, s.Password)
 	headers := map[string]string{
 		"Content-Type": contentExecStage,
 	}
 	var job jobID
-	deviceID, _ := s.getDeviceID(device)
-	softwareID, _ := s.getSoftwareID(image)
+	deviceID, _ := s.getDeviceID(ctx, device)
+	softwareID, _ := s.getSoftwareID(ctx, image)
 	stage := fmt.Sprintf(stageXML, deviceID, cleanup)
 	uri := fmt.Sprintf("https://%s/api/space/software-management/packages/%d/exec-stage", s.Host, softwareID)
 
-	resp := r.Send("post", uri, []byte(stage), headers, nil)
+	resp := s.send(ctx, r, "post", uri, []byte(stage), headers, nil)
 	if resp.Error != nil {
 		return 0, resp.Error
 	}
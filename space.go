@@ -1,5 +1,18 @@
 package junos
 
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/scottdware/go-rested"
+)
+
 // All of our HTTP Content-Types we use.
 var (
 	contentDiscoverDevices = "application/vnd.net.juniper.space.device-management.discover-devices+xml;version=2;charset=UTF-8"
@@ -51,3 +64,77 @@ func NewServer(host, user, passwd string) *Space {
 		Password: passwd,
 	}
 }
+
+// spaceClient is the HTTP client used for the API calls. Like go-rested's, it doesn't
+// verify the server's certificate.
+var spaceClient = &http.Client{
+	Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+		},
+	},
+}
+
+// send issues the request in the same way as go-rested's Send, but cancels it once the
+// context is done, so the server doesn't act on a request the caller has given up on.
+func (s *Space) send(ctx context.Context, r *rested.Request, method, uri string, body []byte, headers, query map[string]string) *rested.Response {
+	var data rested.Response
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		data.Error = err
+
+		return &data
+	}
+
+	q := u.Query()
+	for k, v := range query {
+		q.Add(k, v)
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(strings.ToUpper(method), u.String(), bytes.NewReader(body))
+	if err != nil {
+		data.Error = err
+
+		return &data
+	}
+	req = req.WithContext(ctx)
+
+	if len(r.Auth) > 0 {
+		req.SetBasicAuth(r.Auth[0], r.Auth[1])
+	}
+
+	for k, v := range headers {
+		req.Header.Add(k, v)
+	}
+
+	res, err := spaceClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		data.Error = err
+
+		return &data
+	}
+	defer res.Body.Close()
+
+	payload, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		data.Error = err
+
+		return &data
+	}
+
+	data.Body = payload
+	data.Code = res.StatusCode
+	data.Status = res.Status
+	data.Headers = res.Header
+
+	if res.StatusCode >= 400 {
+		data.Error = fmt.Errorf("HTTP %d: %s", res.StatusCode, string(payload))
+	}
+
+	return &data
+}
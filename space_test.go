@@ -0,0 +1,67 @@
+package junos
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSpaceServer returns a Space talking to a test HTTPS server using handler.
+func newSpaceServer(t *testing.T, handler http.HandlerFunc) *Space {
+	t.Helper()
+
+	srv := httptest.NewTLSServer(handler)
+	t.Cleanup(srv.Close)
+
+	return NewServer(strings.TrimPrefix(srv.URL, "https://"), "admin", "secret")
+}
+
+func TestSpaceDevices(t *testing.T) {
+	s := newSpaceServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`<devices><device key="1234"><name>fw1</name><ipAddr>192.0.2.1</ipAddr></device></devices>`))
+	})
+
+	devices, err := s.Devices()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devices.Devices) != 1 || devices.Devices[0].Name != "fw1" {
+		t.Errorf("Devices returned %+v", devices.Devices)
+	}
+}
+
+func TestSpaceContextCancelsRequest(t *testing.T) {
+	cancelled := make(chan struct{})
+	s := newSpaceServer(t, func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+
+		select {
+		case <-r.Context().Done():
+			close(cancelled)
+		case <-time.After(10 * time.Second):
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if _, err := s.AddDeviceContext(ctx, "fw1", "admin", "secret"); err != context.DeadlineExceeded {
+		t.Fatalf("AddDeviceContext returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request wasn't cancelled on the server")
+	}
+}
@@ -4,8 +4,6 @@ import (
 	"encoding/xml"
 	"fmt"
 	"os"
-	"regexp"
-	"strconv"
 	"strings"
 )
 
@@ -471,8 +469,6 @@ type ZoneInterface struct {
 // ConvertAddressBook will generate the configuration needed to migrate from a zone-based address
 // book to a global one. You can then use Config() to apply the changes if necessary.
 func (j *Junos) ConvertAddressBook() []string {
-	vrx := regexp.MustCompile(`(\d+)\.(\d+)([RBISX]{1})(\d+)(\.(\d+))?`)
-
 	for _, d := range j.Platform {
 		if strings.Contains(d.Model, "FIREFLY") {
 			continue
@@ -482,13 +478,8 @@ func (j *Junos) ConvertAddressBook() []string {
 			fmt.Printf("This device doesn't look to be an SRX (%s). You can only run this script against an SRX.\n", d.Model)
 			os.Exit(0)
 		}
-		versionBreak := vrx.FindStringSubmatch(d.Version)
-		maj, _ := strconv.Atoi(versionBreak[1])
-		min, _ := strconv.Atoi(versionBreak[2])
-		// rel := versionBreak[3]
-		// build, _ := strconv.Atoi(versionBreak[4])
 
-		if maj <= 11 && min < 2 {
+		if d.ParsedVersion.Compare(Version{Major: 11, Minor: 2}) < 0 {
 			fmt.Println("You must be running JUNOS version 11.2 or above in order to use this conversion tool.")
 			os.Exit(0)
 		}
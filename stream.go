@@ -0,0 +1,326 @@
+package junos
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/Juniper/go-netconf/netconf"
+)
+
+// StreamFunc is called for each element streamed from a reply. Calling decode unmarshals the
+// element into v, the same as xml.Unmarshal would; if it isn't called, the element is skipped.
+// Returning an error stops the stream, and Stream returns that error.
+type StreamFunc func(decode func(v interface{}) error) error
+
+// Stream runs an RPC, and calls fn for each of the elements with the given name in the reply,
+// one at a time as they're read from the device. Unlike the other methods, the reply is never
+// held in memory all at once, so it's suitable for replies that are too large for that, e.g.
+// the full routing table on a core router. The request is the same as it is for RPC.
+//
+// Since the reply is still being read while fn is called, fn must not run any other RPCs on
+// the session. Errors reported by the device are returned as an *RPCError once the whole
+// reply has been read. Sessions that aren't using one of our own transports, or are being
+// recorded, fall back to reading the reply all at once.
+func (j *Junos) Stream(ctx context.Context, request interface{}, element string, fn StreamFunc) error {
+	return j.stream(ctx, request, []string{element}, func(name string, decode func(v interface{}) error) error {
+		return fn(decode)
+	})
+}
+
+// StreamRoutes streams the entries in the routing table (the same ones as View("route")),
+// calling fn for each route along with the name of the table it's in.
+func (j *Junos) StreamRoutes(ctx context.Context, fn func(table string, route Route) error) error {
+	var table string
+
+	return j.stream(ctx, viewCategories["route"], []string{"table-name", "rt"}, func(name string, decode func(v interface{}) error) error {
+		if name == "table-name" {
+			table = ""
+			if err := decode(&table); err != nil {
+				return err
+			}
+			table = strings.TrimSpace(table)
+
+			return nil
+		}
+
+		var route Route
+		if err := decode(&route); err != nil {
+			return err
+		}
+
+		return fn(table, route)
+	})
+}
+
+// StreamInterfaces streams the physical interfaces (the same ones as View("interface")),
+// calling fn for each of them.
+func (j *Junos) StreamInterfaces(ctx context.Context, fn func(iface PhysicalInterface) error) error {
+	return j.Stream(ctx, viewCategories["interface"], "physical-interface", func(decode func(v interface{}) error) error {
+		var iface PhysicalInterface
+		if err := decode(&iface); err != nil {
+			return err
+		}
+
+		return fn(iface)
+	})
+}
+
+// StreamArp streams the ARP table (the same entries as View("arp")), calling fn for each entry.
+func (j *Junos) StreamArp(ctx context.Context, fn func(entry ArpEntry) error) error {
+	return j.Stream(ctx, viewCategories["arp"], "arp-table-entry", func(decode func(v interface{}) error) error {
+		var entry ArpEntry
+		if err := decode(&entry); err != nil {
+			return err
+		}
+
+		return fn(entry)
+	})
+}
+
+// stream runs the RPC, and calls fn for each element in the reply with one of the given names.
+func (j *Junos) stream(ctx context.Context, request interface{}, names []string, fn func(name string, decode func(v interface{}) error) error) error {
+	command, err := marshalRPC(request)
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := j.throttle(ctx); err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+
+	t, ok := j.Session.Transport.(*transport)
+	if !ok || (j.rc != nil && j.rc.dead) {
+		j.mu.Unlock()
+		return j.streamReply(ctx, command, names, fn)
+	}
+
+	err = j.streamTransport(ctx, t, command, names, fn)
+	if se, ok := err.(streamError); ok {
+		err = se.err
+	} else if _, ok := err.(*RPCError); !ok && err != nil && j.rc != nil {
+		j.rc.dead = true
+	}
+
+	j.mu.Unlock()
+
+	return err
+}
+
+// streamReply is the fallback for stream, which reads the whole reply and decodes it. The
+// rate limits have already been waited on by stream.
+func (j *Junos) streamReply(ctx context.Context, command string, names []string, fn func(name string, decode func(v interface{}) error) error) error {
+	reply, err := j.execNow(ctx, command)
+	if err != nil {
+		return err
+	}
+
+	if err := j.replyError(reply); err != nil {
+		return err
+	}
+
+	_, err = decodeStream(ctx, strings.NewReader("<rpc-reply>"+reply.Data+"</rpc-reply>"), names, fn)
+	if se, ok := err.(streamError); ok {
+		return se.err
+	}
+
+	return err
+}
+
+// streamTransport sends the RPC on t, and decodes the reply as it's read. It must be called
+// with j.mu held. Errors that leave the session usable (i.e. the rest of the reply was read)
+// are wrapped in a streamError.
+func (j *Junos) streamTransport(ctx context.Context, t *transport, command string, names []string, fn func(name string, decode func(v interface{}) error) error) error {
+	msg, err := xml.Marshal(netconf.NewRPCMessage([]netconf.RPCMethod{netconf.RawMethod(command)}))
+	if err != nil {
+		return err
+	}
+
+	if err := t.Send(append([]byte(xml.Header), msg...)); err != nil {
+		return err
+	}
+
+	// Closing the transport is the only way to stop a blocked read.
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			j.closeTransport()
+		case <-finished:
+		}
+	}()
+
+	r := &messageReader{r: t.r}
+	errs, err := decodeStream(ctx, r, names, fn)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// Read the rest of the reply, so the session can still be used.
+		if _, derr := io.Copy(ioutil.Discard, r); derr != nil {
+			return derr
+		}
+
+		if se, ok := err.(streamError); ok {
+			return se
+		}
+
+		return streamError{err}
+	}
+
+	_, err = j.checkErrors(errs)
+
+	return err
+}
+
+// streamError wraps an error returned by a StreamFunc, or one that stopped the stream
+// without leaving the session unusable.
+type streamError struct {
+	err error
+}
+
+func (e streamError) Error() string {
+	return e.err.Error()
+}
+
+// decodeStream reads an <rpc-reply> from r, calling fn for each element with one of the
+// given names, and returns the <rpc-error>s at the top of the reply.
+func decodeStream(ctx context.Context, r io.Reader, names []string, fn func(name string, decode func(v interface{}) error) error) ([]*RPCError, error) {
+	d := xml.NewDecoder(r)
+	depth := 0
+
+	var errs []*RPCError
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return errs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 1 && t.Name.Local == "rpc-error" {
+				e := &RPCError{}
+				if err := d.DecodeElement(e, &t); err != nil {
+					return nil, err
+				}
+				errs = append(errs, e)
+
+				continue
+			}
+
+			if !matches(t.Name.Local, names) {
+				depth++
+				continue
+			}
+
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			decoded := false
+			decode := func(v interface{}) error {
+				if decoded {
+					return nil
+				}
+				decoded = true
+
+				return d.DecodeElement(v, &t)
+			}
+
+			if err := fn(t.Name.Local, decode); err != nil {
+				if !decoded {
+					d.Skip()
+				}
+
+				return nil, streamError{err}
+			}
+
+			if !decoded {
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// matches returns whether name is one of names.
+func matches(name string, names []string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// messageReader reads a single message from the transport, stopping at the end-of-message
+// separator.
+type messageReader struct {
+	r    *bufio.Reader
+	buf  []byte
+	held []byte
+	done bool
+}
+
+func (m *messageReader) Read(p []byte) (int, error) {
+	sep := []byte(msgSeparator)
+
+	for len(m.buf) == 0 {
+		if m.done {
+			return 0, io.EOF
+		}
+
+		chunk, err := m.r.ReadSlice('>')
+		m.held = append(m.held, chunk...)
+
+		if bytes.HasSuffix(m.held, sep) {
+			m.buf = m.held[:len(m.held)-len(sep)]
+			m.held = nil
+			m.done = true
+			break
+		}
+
+		if err != nil && err != bufio.ErrBufferFull {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return 0, err
+		}
+
+		// Hold back anything that could be the start of the separator.
+		k := len(sep) - 1
+		for ; k > 0; k-- {
+			if len(m.held) >= k && bytes.HasSuffix(m.held, sep[:k]) {
+				break
+			}
+		}
+
+		m.buf = append([]byte(nil), m.held[:len(m.held)-k]...)
+		m.held = append([]byte(nil), m.held[len(m.held)-k:]...)
+	}
+
+	n := copy(p, m.buf)
+	m.buf = m.buf[n:]
+
+	return n, nil
+}
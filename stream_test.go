@@ -0,0 +1,162 @@
+package junos_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+// routes returns the reply to get-route-information with n routes in inet.0, and a default
+// route in inet6.0.
+func routes(n int) string {
+	var b strings.Builder
+
+	b.WriteString("<route-information><route-table><table-name>inet.0</table-name>\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "<rt><rt-destination>10.%d.%d.0/24</rt-destination><rt-entry><protocol-name>BGP</protocol-name>"+
+			"<preference>170</preference><nh><to>192.0.2.1</to><via>ge-0/0/0.0</via></nh></rt-entry></rt>\n", i/256, i%256)
+	}
+	b.WriteString("</route-table><route-table><table-name>inet6.0</table-name><rt><rt-destination>::/0</rt-destination></rt></route-table>")
+	b.WriteString("</route-information>")
+
+	return b.String()
+}
+
+func TestStreamRoutes(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Handle("get-route-information", routes(20000))
+
+	tables := map[string]int{}
+	var first junos.Route
+	err := j.StreamRoutes(context.Background(), func(table string, r junos.Route) error {
+		if len(tables) == 0 {
+			first = r
+		}
+		tables[table]++
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tables["inet.0"] != 20000 || tables["inet6.0"] != 1 {
+		t.Errorf("got routes %v", tables)
+	}
+
+	if first.Destination != "10.0.0.0/24" || first.NextHop != "192.0.2.1" {
+		t.Errorf("got first route %+v", first)
+	}
+}
+
+func TestStreamStop(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Handle("get-route-information", routes(10000))
+
+	stop := errors.New("stop")
+	n := 0
+	err := j.StreamRoutes(context.Background(), func(string, junos.Route) error {
+		if n++; n == 10 {
+			return stop
+		}
+
+		return nil
+	})
+	if err != stop {
+		t.Fatalf("StreamRoutes returned %v, want %v", err, stop)
+	}
+
+	if v, err := j.View("arp"); err != nil || v.Arp.Count != 1 {
+		t.Errorf("the session can't be used after stopping a stream - %v", err)
+	}
+}
+
+func TestStreamArpAndInterfaces(t *testing.T) {
+	j, _ := newSession(t)
+	ctx := context.Background()
+
+	var entries []junos.ArpEntry
+	err := j.StreamArp(ctx, func(e junos.ArpEntry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil || len(entries) != 1 || entries[0].IPAddress != "192.0.2.254" {
+		t.Errorf("StreamArp returned %+v, %v", entries, err)
+	}
+
+	var names []string
+	err = j.StreamInterfaces(ctx, func(i junos.PhysicalInterface) error {
+		names = append(names, i.Name)
+		return nil
+	})
+	if err != nil || len(names) != 1 || names[0] != "ge-0/0/0" {
+		t.Errorf("StreamInterfaces returned %v, %v", names, err)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	j, srv := newSession(t)
+	ctx := context.Background()
+
+	var rpcErr *junos.RPCError
+	err := j.Stream(ctx, "<get-nothing-at-all/>", "nothing", func(func(interface{}) error) error { return nil })
+	if !errors.As(err, &rpcErr) {
+		t.Errorf("Stream returned %#v for an unknown RPC", err)
+	}
+
+	srv.Handle("get-arp-table-information", junostest.RPCError("application", "warning", "warning", "ARP table is stale")+junostest.ArpTableInformation)
+
+	n := 0
+	err = j.StreamArp(ctx, func(junos.ArpEntry) error {
+		n++
+		return nil
+	})
+	if err != nil || n != 1 {
+		t.Errorf("StreamArp returned %v after %d entries for a reply with a warning", err, n)
+	}
+}
+
+func TestStreamRecorded(t *testing.T) {
+	j, _ := newSession(t)
+
+	var buf bytes.Buffer
+	if err := j.Record(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	n := 0
+	err := j.StreamArp(context.Background(), func(junos.ArpEntry) error {
+		n++
+		return nil
+	})
+	if err != nil || n != 1 {
+		t.Fatalf("StreamArp returned %v after %d entries", err, n)
+	}
+
+	if !strings.Contains(buf.String(), "<arp-table-entry>") {
+		t.Error("the streamed reply wasn't recorded")
+	}
+}
+
+func TestStreamContextCancel(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Handle("get-route-information", routes(50000))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := j.StreamRoutes(ctx, func(string, junos.Route) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("StreamRoutes returned %v, want %v", err, context.DeadlineExceeded)
+	}
+}
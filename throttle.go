@@ -0,0 +1,185 @@
+package junos
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// transientErrors are the messages (in lower case) of errors that go away on their own,
+// usually once another user, or commit, is done with the configuration database.
+var transientErrors = []string{
+	"configuration database locked",
+	"database is locked",
+	"mgd busy",
+	"mgd is busy",
+	"commit is in progress",
+}
+
+// transientTags are the error tags of errors that go away on their own.
+var transientTags = []string{
+	"lock-denied",
+	"in-use",
+	"resource-denied",
+}
+
+// RateLimiter limits how often RPCs are sent. Sessions can each have their own, to avoid
+// overloading a single device, or share one, to limit the RPCs sent across a whole fleet
+// (see Junos.RateLimit and Fleet.RateLimit). RPCs are spread out evenly, rather than being
+// sent in bursts.
+type RateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewRateLimiter returns a RateLimiter that allows the given number of RPCs in each period,
+// e.g. NewRateLimiter(10, time.Second) allows 10 RPCs a second.
+func NewRateLimiter(rpcs int, per time.Duration) *RateLimiter {
+	if rpcs <= 0 {
+		rpcs = 1
+	}
+
+	return &RateLimiter{interval: per / time.Duration(rpcs)}
+}
+
+// Wait blocks until the next RPC is allowed to be sent, or ctx is done.
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	now := time.Now()
+	at := r.next
+	if at.Before(now) {
+		at = now
+	}
+	r.next = at.Add(r.interval)
+	r.mu.Unlock()
+
+	d := at.Sub(now)
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// RetryPolicy controls how operations that fail with a transient error, such as the
+// configuration database being locked by another user, are retried. It applies to Lock,
+// Unlock, loading and committing the configuration, and RPC.
+//
+// MaxAttempts is the number of times the operation is tried (3 by default), waiting Backoff
+// (1 second by default) after the first failed attempt, and doubling that up to MaxBackoff
+// (30 seconds by default) after each one after that. Retryable decides whether an error is
+// transient; if it isn't set, IsTransientError is used.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Retryable   func(err error) bool
+}
+
+// IsTransientError returns whether err is an *RPCError that's likely to go away if the
+// operation is retried, e.g. "configuration database locked", or "mgd busy".
+func IsTransientError(err error) bool {
+	e, ok := err.(*RPCError)
+	if !ok {
+		return false
+	}
+
+	errs := e.Errors
+	if len(errs) == 0 {
+		errs = []*RPCError{e}
+	}
+
+	for _, m := range errs {
+		if m.Severity == "warning" {
+			continue
+		}
+
+		for _, t := range transientTags {
+			if m.Tag == t {
+				return true
+			}
+		}
+
+		msg := strings.ToLower(m.Message)
+		for _, t := range transientErrors {
+			if strings.Contains(msg, t) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// throttle waits until the session's rate limits allow another RPC to be sent.
+func (j *Junos) throttle(ctx context.Context) error {
+	if err := j.fleetLimit.Wait(ctx); err != nil {
+		return err
+	}
+
+	return j.RateLimit.Wait(ctx)
+}
+
+// retry runs fn, retrying it according to the session's RetryPolicy if it fails with a
+// transient error.
+func (j *Junos) retry(ctx context.Context, fn func() error) error {
+	p := j.RetryPolicy
+	if p == nil {
+		return fn()
+	}
+
+	attempts := p.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsTransientError
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= attempts || !retryable(err) {
+			return err
+		}
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
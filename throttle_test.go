@@ -0,0 +1,164 @@
+package junos_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+func TestRateLimit(t *testing.T) {
+	j, _ := newSession(t)
+	j.RateLimit = junos.NewRateLimiter(10, time.Second)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := j.RPC("<get-system-uptime-information/>", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if d := time.Since(start); d < 350*time.Millisecond {
+		t.Errorf("5 RPCs at 10 a second took %s", d)
+	}
+}
+
+func TestRateLimitContext(t *testing.T) {
+	j, _ := newSession(t)
+	j.RateLimit = junos.NewRateLimiter(1, time.Minute)
+
+	if err := j.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := j.PingContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("PingContext returned %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	j, srv := newSession(t)
+
+	failures := 0
+	srv.HandleFunc("lock-configuration", func(*junostest.Request) string {
+		if failures > 0 {
+			failures--
+			return junostest.LockError()
+		}
+
+		return "<ok/>"
+	})
+
+	failures = 2
+	if err := j.Lock(); err == nil {
+		t.Fatal("Lock succeeded without a retry policy")
+	}
+
+	j.RetryPolicy = &junos.RetryPolicy{Backoff: 10 * time.Millisecond}
+
+	failures = 2
+	srv.Reset()
+	if err := j.Lock(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := srv.Received("lock-configuration"); n != 3 {
+		t.Errorf("Lock was tried %d times, want 3", n)
+	}
+
+	failures = 5
+	if err := j.Lock(); !junos.IsTransientError(err) {
+		t.Errorf("Lock returned %v after running out of attempts", err)
+	}
+
+	srv.Handle("commit-configuration", junostest.CommitError("[edit]", "system", "commit failed"))
+	srv.Reset()
+	if err := j.Commit(); err == nil || junos.IsTransientError(err) {
+		t.Errorf("Commit returned %v", err)
+	}
+
+	if n := srv.Received("commit-configuration"); n != 1 {
+		t.Errorf("a commit that failed for good was tried %d times", n)
+	}
+}
+
+func TestSetCommitTimeout(t *testing.T) {
+	j, _ := newSession(t)
+
+	j.SetCommitTimeout(1)
+	if j.RateLimit != nil {
+		t.Error("SetCommitTimeout set a rate limit")
+	}
+
+	// Only Lock, Commit and Unlock are delayed.
+	start := time.Now()
+	if err := j.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.GetConfig("text"); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("RPCs other than Lock, Commit and Unlock took %s", d)
+	}
+
+	start = time.Now()
+	if err := j.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < time.Second {
+		t.Errorf("Lock only took %s with a 1 second commit timeout", d)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := j.UnlockContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("UnlockContext returned %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	j.SetCommitTimeout(0)
+	start = time.Now()
+	if err := j.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("Commit took %s without a commit timeout", d)
+	}
+}
+
+func TestFleetRateLimitFacts(t *testing.T) {
+	srv := newServer(t)
+
+	// Connecting to the device, and gathering its facts, takes 6 RPCs, so at 20 a second
+	// the run takes at least 250ms, but only if the facts are rate limited.
+	for name, fleet := range map[string]*junos.Fleet{
+		"RateLimit": {
+			Hosts:     []string{srv.Addr},
+			Auth:      srv.Auth(),
+			RateLimit: junos.NewRateLimiter(20, time.Second),
+		},
+		"SessionRateLimit": {
+			Hosts:            []string{srv.Addr},
+			Auth:             srv.Auth(),
+			SessionRateLimit: func() *junos.RateLimiter { return junos.NewRateLimiter(20, time.Second) },
+		},
+	} {
+		start := time.Now()
+		results := fleet.Run(context.Background(), func(context.Context, *junos.Junos) (interface{}, error) {
+			return nil, nil
+		})
+
+		if err := results.Results[0].Err; err != nil {
+			t.Fatal(err)
+		}
+
+		if d := time.Since(start); d < 200*time.Millisecond {
+			t.Errorf("with a fleet %s, connecting took %s, so the facts weren't rate limited", name, d)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package junos
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"github.com/Juniper/go-netconf/netconf"
+)
+
+// NewSessionTLS establishes a new NETCONF over TLS (RFC 7589) connection to a Junos device that
+// we will use to run our commands against. Authentication is done using the certificates in
+// config, so it will usually contain the client certificate to present, and the CAs used to
+// verify the device's certificate. If host doesn't specify a port, the default NETCONF over
+// TLS port (6513) is used.
+//
+// If config.ServerName is empty, the device's certificate is verified against the hostname
+// given in host. See SessionOption for the options.
+func NewSessionTLS(host string, config *tls.Config, options ...SessionOption) (*Junos, error) {
+	return NewSessionTLSContext(context.Background(), host, config, options...)
+}
+
+// NewSessionTLSContext is the same as NewSessionTLS, but gives up connecting to the device
+// once the given context is cancelled or its deadline passes.
+func NewSessionTLSContext(ctx context.Context, host string, config *tls.Config, options ...SessionOption) (*Junos, error) {
+	return newSessionTLS(ctx, host, config, newSessionConfig(options))
+}
+
+// newSessionTLS establishes the session for NewSessionTLSContext, with the given options.
+func newSessionTLS(ctx context.Context, host string, config *tls.Config, cfg sessionConfig) (*Junos, error) {
+	addr := host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "6513")
+	}
+
+	tlsConfig := &tls.Config{}
+	if config != nil {
+		tlsConfig = config.Clone()
+	}
+
+	if tlsConfig.ServerName == "" {
+		name, _, _ := net.SplitHostPort(addr)
+		tlsConfig.ServerName = name
+	}
+
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s - %s", host, err)
+	}
+
+	s, err := dialTLS(ctx, tls.Client(nc, tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s - %s", host, err)
+	}
+
+	j, err := newSessionFromNetconf(ctx, s, cfg)
+	if j != nil {
+		j.redial = func(ctx context.Context, cfg sessionConfig) (*Junos, error) {
+			return newSessionTLS(ctx, host, config, cfg)
+		}
+	}
+
+	return j, err
+}
+
+// dialTLS performs the TLS handshake and starts a NETCONF session, giving up once ctx is done.
+func dialTLS(ctx context.Context, conn *tls.Conn) (*netconf.Session, error) {
+	type result struct {
+		s   *netconf.Session
+		err error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		if err := conn.Handshake(); err != nil {
+			done <- result{nil, err}
+			return
+		}
+
+		done <- result{netconf.NewSession(newTransport(conn)), nil}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			conn.Close()
+		}
+
+		return r.s, r.err
+	case <-ctx.Done():
+		conn.Close()
+		return nil, ctx.Err()
+	}
+}
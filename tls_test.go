@@ -0,0 +1,118 @@
+package junos_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+// newCertificate returns a self-signed certificate for localhost, which is used as both the
+// CA and the server and client certificates, and a pool containing it.
+func newCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+// newTLSServer returns a new NETCONF over TLS test server requiring a client certificate
+// signed by pool, and its address using the name localhost.
+func newTLSServer(t *testing.T, cert tls.Certificate, pool *x509.CertPool) (*junostest.Server, string) {
+	t.Helper()
+
+	srv := junostest.NewTLSServer(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	})
+	t.Cleanup(srv.Close)
+
+	_, port, _ := net.SplitHostPort(srv.Addr)
+
+	return srv, net.JoinHostPort("localhost", port)
+}
+
+func TestNewSessionTLS(t *testing.T) {
+	cert, pool := newCertificate(t)
+	srv, addr := newTLSServer(t, cert, pool)
+
+	j, err := junos.NewSessionTLS(addr, &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer j.Close()
+
+	if j.Hostname == "" {
+		t.Error("the facts weren't gathered over TLS")
+	}
+
+	if err := j.Ping(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := srv.Sessions(); n != 1 {
+		t.Errorf("the server has seen %d sessions, want 1", n)
+	}
+}
+
+func TestNewSessionTLSNoClientCertificate(t *testing.T) {
+	cert, pool := newCertificate(t)
+	_, addr := newTLSServer(t, cert, pool)
+
+	j, err := junos.NewSessionTLS(addr, &tls.Config{RootCAs: pool})
+	if err == nil {
+		j.Close()
+		t.Fatal("NewSessionTLS succeeded without a client certificate")
+	}
+}
+
+func TestNewSessionTLSUntrustedServer(t *testing.T) {
+	cert, pool := newCertificate(t)
+	_, addr := newTLSServer(t, cert, pool)
+	_, other := newCertificate(t)
+
+	j, err := junos.NewSessionTLS(addr, &tls.Config{RootCAs: other, Certificates: []tls.Certificate{cert}})
+	if err == nil {
+		j.Close()
+		t.Fatal("NewSessionTLS trusted a server certificate that isn't signed by RootCAs")
+	}
+}
@@ -0,0 +1,149 @@
+package junos
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Transaction is a configuration change that's applied in one go (see Apply): the
+// configuration is locked, the changes loaded, checked and committed, and the configuration
+// unlocked again. If anything fails along the way, the changes are discarded and the
+// configuration is unlocked.
+//
+// Mode is the configuration database the changes are made in (ConfigExclusive, i.e. the
+// locked candidate configuration, by default). Changes holds the configurations to load, in
+// order.
+//
+// Once the changes are loaded, Review (if set) is given the diff, and returning an error
+// from it aborts the transaction. If CommitCheck is set, the changes are checked before they
+// are committed. If DryRun is set, the transaction stops there, and the changes are
+// discarded rather than committed. Transactions that don't change anything aren't committed.
+//
+// If Confirm is more than zero, the changes are committed using a confirmed commit, which
+// the device rolls back after that many minutes unless it's confirmed. Verify (if set) is
+// called after the confirmed commit, e.g. to check the device is still reachable; if it
+// returns an error, the commit isn't confirmed, so the device rolls the changes back.
+// Comment is the log message for the commit.
+type Transaction struct {
+	Mode        ConfigMode
+	Changes     []*LoadOptions
+	Review      func(diff string) error
+	CommitCheck bool
+	DryRun      bool
+	Confirm     int
+	Verify      func(ctx context.Context, j *Junos) error
+	Comment     string
+
+	j *Junos
+}
+
+// TransactionResult holds what happened when a transaction was applied. Diff is the change
+// made by the transaction (empty if nothing changed). Committed is set once the changes are
+// committed (and confirmed, for a confirmed commit).
+type TransactionResult struct {
+	Diff      string
+	Load      []*LoadResult
+	Check     *CommitResult
+	Commit    *CommitResult
+	Committed bool
+}
+
+// NewTransaction returns a transaction on the session, which loads the given configurations.
+// Set the transaction's fields to choose the steps it runs, before calling Apply.
+func (j *Junos) NewTransaction(changes ...*LoadOptions) *Transaction {
+	return &Transaction{
+		Mode:    ConfigExclusive,
+		Changes: changes,
+		j:       j,
+	}
+}
+
+// Apply runs the transaction. The result is returned even if the transaction fails, with
+// whatever steps completed filled in. The changes are always discarded, and the
+// configuration unlocked, if the transaction fails (including if Review or Verify panic).
+func (t *Transaction) Apply(ctx context.Context) (*TransactionResult, error) {
+	if t.j == nil {
+		return nil, errors.New("transactions must be created using NewTransaction")
+	}
+
+	if len(t.Changes) == 0 {
+		return nil, errors.New("the transaction has no changes to load")
+	}
+
+	mode := t.Mode
+	if mode == 0 {
+		mode = ConfigExclusive
+	}
+
+	res := &TransactionResult{}
+	err := t.j.WithConfigSession(ctx, mode, func(cs *ConfigSession) error {
+		return t.apply(ctx, cs, res)
+	})
+
+	return res, err
+}
+
+// apply runs the steps of the transaction in the open configuration database.
+func (t *Transaction) apply(ctx context.Context, cs *ConfigSession, res *TransactionResult) error {
+	for _, c := range t.Changes {
+		lr, err := cs.Load(ctx, c)
+		if err != nil {
+			return err
+		}
+		res.Load = append(res.Load, lr)
+	}
+
+	diff, err := cs.Diff(ctx)
+	if err != nil {
+		return err
+	}
+	res.Diff = diff
+
+	if strings.TrimSpace(diff) == "" {
+		return nil
+	}
+
+	if t.Review != nil {
+		if err := t.Review(diff); err != nil {
+			return err
+		}
+	}
+
+	if t.CommitCheck {
+		if res.Check, err = cs.CommitCheck(ctx); err != nil {
+			return err
+		}
+	}
+
+	if t.DryRun {
+		return nil
+	}
+
+	if t.Confirm <= 0 {
+		if res.Commit, err = cs.CommitWithOptions(ctx, &CommitOptions{Comment: t.Comment}); err != nil {
+			return err
+		}
+		res.Committed = true
+
+		return nil
+	}
+
+	opts := &CommitOptions{Comment: t.Comment, Confirmed: t.Confirm}
+	if res.Commit, err = cs.CommitWithOptions(ctx, opts); err != nil {
+		return err
+	}
+
+	if t.Verify != nil {
+		if err := t.Verify(ctx, t.j); err != nil {
+			return err
+		}
+	}
+
+	if _, err := cs.CommitWithOptions(ctx, &CommitOptions{Comment: t.Comment}); err != nil {
+		return err
+	}
+	res.Committed = true
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package junos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+// hostname is a change to the configuration, for the transaction tests.
+var hostname = &junos.LoadOptions{Format: "set", Lines: []string{"set system host-name fw2"}}
+
+func TestTransaction(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Reset()
+
+	tx := j.NewTransaction(hostname)
+	tx.CommitCheck = true
+	tx.Confirm = 5
+
+	verified := false
+	tx.Verify = func(context.Context, *junos.Junos) error {
+		verified = true
+		return nil
+	}
+
+	res, err := tx.Apply(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !res.Committed || res.Diff == "" || !verified {
+		t.Errorf("got result %+v, verified = %v", res, verified)
+	}
+
+	// The check, the confirmed commit, and the commit confirming it.
+	if n := srv.Received("commit-configuration"); n != 3 {
+		t.Errorf("got %d commits, want 3", n)
+	}
+
+	if srv.Received("lock-configuration") != 1 || srv.Received("unlock-configuration") != 1 {
+		t.Errorf("the configuration wasn't locked and unlocked: %+v", srv.Requests())
+	}
+}
+
+func TestTransactionReview(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Reset()
+
+	rejected := errors.New("rejected")
+	tx := j.NewTransaction(hostname)
+	tx.Review = func(string) error {
+		return rejected
+	}
+
+	res, err := tx.Apply(context.Background())
+	if err != rejected {
+		t.Errorf("Apply returned %v, want %v", err, rejected)
+	}
+
+	if res.Committed || srv.Received("commit-configuration") != 0 {
+		t.Error("a rejected transaction was committed")
+	}
+
+	if n := srv.Received("unlock-configuration"); n != 1 {
+		t.Errorf("the configuration was unlocked %d times, want 1", n)
+	}
+}
+
+func TestTransactionPanic(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Reset()
+
+	tx := j.NewTransaction(hostname)
+	tx.Review = func(string) error {
+		panic("failed")
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Apply didn't re-panic")
+			}
+		}()
+
+		tx.Apply(context.Background())
+	}()
+
+	// The change, and then the rollback discarding it.
+	if srv.Received("load-configuration") != 2 || srv.Received("unlock-configuration") != 1 {
+		t.Errorf("the changes weren't discarded after a panic: %+v", srv.Requests())
+	}
+}
+
+func TestTransactionCommitCheckFails(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Handle("commit-configuration", junostest.CommitError("[edit system]", "host-name", "invalid host-name"))
+	srv.Reset()
+
+	tx := j.NewTransaction(hostname)
+	tx.CommitCheck = true
+
+	res, err := tx.Apply(context.Background())
+	if err == nil || res.Committed {
+		t.Errorf("Apply returned %+v, %v", res, err)
+	}
+
+	if srv.Received("commit-configuration") != 1 || srv.Received("unlock-configuration") != 1 {
+		t.Errorf("got requests %+v", srv.Requests())
+	}
+}
+
+func TestTransactionDryRun(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Reset()
+
+	tx := j.NewTransaction(hostname)
+	tx.DryRun = true
+
+	res, err := tx.Apply(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.Committed || res.Diff == "" || srv.Received("commit-configuration") != 0 {
+		t.Errorf("a dry run returned %+v, and sent %+v", res, srv.Requests())
+	}
+}
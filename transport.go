@@ -0,0 +1,218 @@
+package junos
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/Juniper/go-netconf/netconf"
+	"golang.org/x/crypto/ssh"
+)
+
+// msgSeparator marks the end of each NETCONF 1.0 message.
+const msgSeparator = "]]>]]>"
+
+// transport implements netconf.Transport over any stream, using the NETCONF 1.0
+// end-of-message framing. For SSH transports, client is the underlying SSH connection.
+type transport struct {
+	rw      io.ReadWriteCloser
+	r       *bufio.Reader
+	closers []io.Closer
+	client  *ssh.Client
+}
+
+// readWriteCloser joins the stdout and stdin pipes of an SSH session. Closing it closes
+// the session, rather than just stdin, since sending EOF on the channel races with an RPC
+// that's still being written when we give up on it.
+type readWriteCloser struct {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
+// newTransport returns a transport that reads and writes messages on rw. Closing the
+// transport closes rw, followed by each of the closers.
+func newTransport(rw io.ReadWriteCloser, closers ...io.Closer) *transport {
+	return &transport{
+		rw:      rw,
+		r:       bufio.NewReaderSize(rw, 64*1024),
+		closers: closers,
+	}
+}
+
+// newSSHTransport starts the NETCONF subsystem on the given SSH client.
+func newSSHTransport(client *ssh.Client) (*transport, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := session.StdinPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	r, err := session.StdoutPipe()
+	if err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	if err := session.RequestSubsystem("netconf"); err != nil {
+		session.Close()
+		return nil, err
+	}
+
+	t := newTransport(readWriteCloser{r, w, session}, client)
+	t.client = client
+
+	return t, nil
+}
+
+// dialSSH performs the SSH handshake over nc and starts a NETCONF session, giving up once
+// ctx is done. The host key presented is verified as belonging to host.
+func dialSSH(ctx context.Context, host string, nc net.Conn, config *ssh.ClientConfig) (*netconf.Session, error) {
+	client, err := sshHandshake(ctx, nc, netconfAddr(host), config)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		s   *netconf.Session
+		err error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		t, err := newSSHTransport(client)
+		if err != nil {
+			client.Close()
+			done <- result{nil, err}
+			return
+		}
+
+		done <- result{netconf.NewSession(t), nil}
+	}()
+
+	select {
+	case r := <-done:
+		return r.s, r.err
+	case <-ctx.Done():
+		client.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// sshHandshake establishes an SSH client connection to addr over nc, giving up once ctx is
+// done. If the host key can't be verified, the *HostKeyError is returned as is.
+func sshHandshake(ctx context.Context, nc net.Conn, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	config, hostKeyErr := verifyHostAs(config, addr)
+
+	type result struct {
+		client *ssh.Client
+		err    error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		c, chans, reqs, err := ssh.NewClientConn(nc, addr, config)
+		if err != nil {
+			done <- result{nil, err}
+			return
+		}
+
+		done <- result{ssh.NewClient(c, chans, reqs), nil}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			nc.Close()
+			if err := hostKeyErr(); err != nil {
+				return nil, err
+			}
+		}
+
+		return r.client, r.err
+	case <-ctx.Done():
+		nc.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// Send writes a message, followed by the end-of-message separator.
+func (t *transport) Send(data []byte) error {
+	msg := make([]byte, 0, len(data)+len(msgSeparator)+1)
+	msg = append(msg, data...)
+	msg = append(msg, msgSeparator+"\n"...)
+
+	_, err := t.rw.Write(msg)
+
+	return err
+}
+
+// Receive reads the next message, without the end-of-message separator.
+func (t *transport) Receive() ([]byte, error) {
+	var buf bytes.Buffer
+	sep := []byte(msgSeparator)
+
+	for {
+		b, err := t.r.ReadSlice('>')
+		buf.Write(b)
+
+		if bytes.HasSuffix(buf.Bytes(), sep) {
+			return bytes.TrimSpace(buf.Bytes()[:buf.Len()-len(sep)]), nil
+		}
+
+		if err != nil && err != bufio.ErrBufferFull {
+			if err == io.EOF && buf.Len() > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return nil, err
+		}
+	}
+}
+
+// SendHello sends our hello message.
+func (t *transport) SendHello(hello *netconf.HelloMessage) error {
+	data, err := xml.Marshal(hello)
+	if err != nil {
+		return err
+	}
+
+	return t.Send(append([]byte(xml.Header), data...))
+}
+
+// ReceiveHello reads the hello message sent by the device.
+func (t *transport) ReceiveHello() (*netconf.HelloMessage, error) {
+	hello := new(netconf.HelloMessage)
+
+	data, err := t.Receive()
+	if err != nil {
+		return hello, err
+	}
+
+	if err := xml.Unmarshal(data, hello); err != nil {
+		return hello, fmt.Errorf("invalid hello message - %s", err)
+	}
+
+	return hello, nil
+}
+
+// Close closes the stream, followed by anything else the transport was using.
+func (t *transport) Close() error {
+	err := t.rw.Close()
+	for _, c := range t.closers {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,229 @@
+package junos
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// versionRegex matches a Junos version, e.g. 12.3X48-D75, 18.4R2-S3, 17.3R3.10 or
+// 21.4R3-S1.5-EVO. Everything after the major and minor version is optional, so that
+// constraints can refer to a whole release (e.g. "15.1X49").
+var versionRegex = regexp.MustCompile(`(\d+)\.(\d+)(?:([A-Z])(\d+)(?:\.(\d+))?(?:-([A-Z])(\d+)(?:\.(\d+))?)?)?(-EVO)?`)
+
+// versionTypes orders the release types within the same major and minor version. Internal
+// and beta builds come before the R releases, which come before the special (X) releases.
+var versionTypes = map[string]int{
+	"I": 1,
+	"B": 2,
+	"R": 3,
+	"F": 4,
+	"S": 5,
+	"X": 6,
+}
+
+// Version is a Junos version, e.g. 18.4R2-S3, which breaks down as:
+//
+//	Major.Minor Type Build [.Spin] [-Service ServiceBuild [.ServiceSpin]] [-EVO]
+//	18   .4     R    2             -S       3
+//
+// Versions are compared (see Compare) component by component, in the order above. A
+// version can leave off everything after its minor version, e.g. "15.1", or after its
+// build, e.g. "15.1X49".
+type Version struct {
+	Major        int
+	Minor        int
+	Type         string
+	Build        int
+	Spin         int
+	Service      string
+	ServiceBuild int
+	ServiceSpin  int
+	EVO          bool
+
+	raw string
+	// components is how many of the components were given, from 2 (major and minor)
+	// through 7 (everything up to the service spin).
+	components int
+}
+
+// ParseVersion parses a Junos version, e.g. "18.4R2-S3" or "12.3X48-D75".
+func ParseVersion(s string) (Version, error) {
+	s = strings.TrimSpace(s)
+
+	m := versionRegex.FindStringSubmatch(strings.ToUpper(s))
+	if m == nil || m[0] != strings.ToUpper(s) {
+		return Version{}, fmt.Errorf("invalid Junos version %q", s)
+	}
+
+	v := Version{
+		Type:    m[3],
+		Service: m[6],
+		EVO:     m[9] != "",
+		raw:     s,
+	}
+
+	nums := []*int{&v.Major, &v.Minor, nil, &v.Build, &v.Spin, &v.ServiceBuild, &v.ServiceSpin}
+	groups := []string{m[1], m[2], m[3], m[4], m[5], m[7], m[8]}
+
+	for i, g := range groups {
+		if g == "" {
+			continue
+		}
+		v.components = i + 1
+
+		if nums[i] != nil {
+			*nums[i], _ = strconv.Atoi(g)
+		}
+	}
+
+	return v, nil
+}
+
+// findVersion returns the first Junos version in s, e.g. from the package comment
+// "JUNOS Software Release [18.4R2-S3]", or an empty string if there isn't one.
+func findVersion(s string) string {
+	if i := strings.Index(s, "["); i >= 0 {
+		if j := strings.Index(s[i:], "]"); j >= 0 {
+			s = s[i+1 : i+j]
+		}
+	}
+
+	return versionRegex.FindString(s)
+}
+
+// String returns the version, as it was given to ParseVersion.
+func (v Version) String() string {
+	if v.raw != "" {
+		return v.raw
+	}
+
+	s := fmt.Sprintf("%d.%d", v.Major, v.Minor)
+	if v.Type != "" {
+		s += fmt.Sprintf("%s%d", v.Type, v.Build)
+	}
+	if v.Spin != 0 {
+		s += fmt.Sprintf(".%d", v.Spin)
+	}
+	if v.Service != "" {
+		s += fmt.Sprintf("-%s%d", v.Service, v.ServiceBuild)
+	}
+	if v.ServiceSpin != 0 {
+		s += fmt.Sprintf(".%d", v.ServiceSpin)
+	}
+	if v.EVO {
+		s += "-EVO"
+	}
+
+	return s
+}
+
+// Compare returns -1 if v is older than other, 1 if it's newer, and 0 if they're the same
+// version. A version that leaves off components is older than any version that has them,
+// e.g. 15.1 comes before 15.1R1. Whether a version is an EVO release isn't compared.
+func (v Version) Compare(other Version) int {
+	n := v.componentCount()
+	if c := other.componentCount(); c > n {
+		n = c
+	}
+
+	return compareComponents(v.ordered(), other.ordered(), n)
+}
+
+// Satisfies returns whether the version meets the given constraint, e.g. ">= 15.1X49". A
+// constraint is an operator (one of =, !=, <, <=, > or >=, with = assumed if it's left off)
+// followed by a version. Multiple constraints are separated by commas, and must all be met,
+// e.g. ">= 15.1X49, < 19.1".
+//
+// Only the components given in the constraint are compared, so "= 15.1X49" matches
+// 15.1X49-D75, and ">= 11.2" matches 11.2R1.
+func (v Version) Satisfies(constraint string) (bool, error) {
+	for _, c := range strings.Split(constraint, ",") {
+		c = strings.TrimSpace(c)
+
+		op := "="
+		for _, o := range []string{">=", "<=", "!=", "==", ">", "<", "="} {
+			if strings.HasPrefix(c, o) {
+				op = o
+				c = strings.TrimSpace(strings.TrimPrefix(c, o))
+				break
+			}
+		}
+
+		want, err := ParseVersion(c)
+		if err != nil {
+			return false, fmt.Errorf("invalid version constraint %q - %s", constraint, err)
+		}
+
+		cmp := compareComponents(v.ordered(), want.ordered(), want.componentCount())
+
+		var ok bool
+		switch op {
+		case "=", "==":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		}
+
+		if !ok {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// componentCount returns how many of the version's components were given.
+func (v Version) componentCount() int {
+	if v.components == 0 && v.raw == "" {
+		// A Version that was built rather than parsed, e.g. Version{Major: 11, Minor: 2}.
+		switch {
+		case v.ServiceSpin != 0:
+			return 7
+		case v.Service != "":
+			return 6
+		case v.Spin != 0:
+			return 5
+		case v.Type != "":
+			return 4
+		default:
+			return 2
+		}
+	}
+
+	return v.components
+}
+
+// ordered returns the version's components in the order they're compared, with the ones
+// that weren't given set to -1.
+func (v Version) ordered() []int {
+	c := []int{v.Major, v.Minor, versionTypes[v.Type], v.Build, v.Spin, v.ServiceBuild, v.ServiceSpin}
+	for i := v.componentCount(); i < len(c); i++ {
+		c[i] = -1
+	}
+
+	return c
+}
+
+// compareComponents compares the first n components of a and b.
+func compareComponents(a, b []int, n int) int {
+	for i := 0; i < n && i < len(a); i++ {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+
+	return 0
+}
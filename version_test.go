@@ -0,0 +1,87 @@
+package junos_test
+
+import (
+	"testing"
+
+	"github.com/scottdware/go-junos"
+)
+
+func TestParseVersion(t *testing.T) {
+	for _, s := range []string{"12.3X48-D75", "18.4R2-S3", "21.4R3-S1.5", "21.4R3-S1.5-EVO", "17.3R3.10", "15.1X49-D170.4", "15.1"} {
+		v, err := junos.ParseVersion(s)
+		if err != nil {
+			t.Errorf("ParseVersion(%q) returned %v", s, err)
+			continue
+		}
+
+		if v.String() != s {
+			t.Errorf("ParseVersion(%q) is %q as a string", s, v.String())
+		}
+	}
+
+	if _, err := junos.ParseVersion("abc"); err == nil {
+		t.Error("ParseVersion succeeded for an invalid version")
+	}
+}
+
+func TestVersionSatisfies(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		want       bool
+	}{
+		{"15.1X49-D75", ">= 15.1X49", true},
+		{"15.1X49-D75", "= 15.1X49", true},
+		{"15.1X49-D75", "> 15.1X49", false},
+		{"12.3X48-D75", ">= 15.1X49", false},
+		{"18.4R2-S3", ">= 15.1X49, < 19.1", true},
+		{"18.4R2-S3", ">18.4R2-S2", true},
+		{"18.4R2-S3", "18.4R2", true},
+		{"21.4R3-S1.5-EVO", "<= 21.4R3-S1.4", false},
+		{"11.1R4", ">= 11.2", false},
+		{"11.2R1", ">= 11.2", true},
+	}
+
+	for _, tt := range tests {
+		v, err := junos.ParseVersion(tt.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := v.Satisfies(tt.constraint)
+		if err != nil || got != tt.want {
+			t.Errorf("%s satisfies %q = %v, %v, want %v", tt.version, tt.constraint, got, err, tt.want)
+		}
+	}
+
+	v, _ := junos.ParseVersion("18.4R2")
+	if _, err := v.Satisfies(">= foo"); err == nil {
+		t.Error("Satisfies succeeded for an invalid constraint")
+	}
+}
+
+func TestVersionCompare(t *testing.T) {
+	a, _ := junos.ParseVersion("18.4R2")
+	b, _ := junos.ParseVersion("18.4R2-S3")
+
+	if a.Compare(b) != -1 || b.Compare(a) != 1 || a.Compare(a) != 0 {
+		t.Errorf("got %d, %d and %d comparing 18.4R2 and 18.4R2-S3", a.Compare(b), b.Compare(a), a.Compare(a))
+	}
+
+	if a.Compare(junos.Version{Major: 11, Minor: 2}) != 1 {
+		t.Error("18.4R2 isn't later than 11.2")
+	}
+}
+
+func TestPlatformVersion(t *testing.T) {
+	j, _ := newSession(t)
+
+	p := j.Platform[0]
+	if p.Version != "18.4R2-S3" || p.ParsedVersion.String() != "18.4R2-S3" {
+		t.Errorf("got platform %+v", p)
+	}
+
+	if ok, err := p.ParsedVersion.Satisfies(">= 15.1X49"); !ok || err != nil {
+		t.Errorf("18.4R2-S3 doesn't satisfy >= 15.1X49: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package junos
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -465,6 +466,12 @@ func validatePlatform(j *Junos, v string) error {
 //
 // View("interface", "ge-0/0/0")
 func (j *Junos) View(view string, option ...string) (*Views, error) {
+	return j.ViewContext(context.Background(), view, option...)
+}
+
+// ViewContext is the same as View, but stops waiting on the device once the given
+// context is done.
+func (j *Junos) ViewContext(ctx context.Context, view string, option ...string) (*Views, error) {
 	var results Views
 	var reply *netconf.RPCReply
 	var err error
@@ -479,21 +486,19 @@ func (j *Junos) View(view string, option ...string) (*Views, error) {
 
 	if view == "interface" && len(option) > 0 {
 		rpcIntName := fmt.Sprintf("<get-interface-information><interface-name>%s</interface-name></get-interface-information>", option[0])
-		reply, err = j.Session.Exec(netconf.RawMethod(rpcIntName))
+		reply, err = j.exec(ctx, rpcIntName)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		reply, err = j.Session.Exec(netconf.RawMethod(viewCategories[view]))
+		reply, err = j.exec(ctx, viewCategories[view])
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	if reply.Errors != nil {
-		for _, m := range reply.Errors {
-			return nil, errors.New(m.Message)
-		}
+	if err := j.replyError(reply); err != nil {
+		return nil, err
 	}
 
 	if reply.Data == "" {
@@ -0,0 +1,92 @@
+package junos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/scottdware/go-junos"
+	"github.com/scottdware/go-junos/junostest"
+)
+
+const warning = `<rpc-error>
+<error-type>application</error-type>
+<error-severity>warning</error-severity>
+<error-path>[edit interfaces]</error-path>
+<error-info><bad-element>ge-0/0/1</bad-element></error-info>
+<error-message>statement has no contents; ignored</error-message>
+</rpc-error>`
+
+// newWarningSession returns a session connected to a test server that reports a warning for
+// every load and commit.
+func newWarningSession(t *testing.T) (*junos.Junos, *junostest.Server) {
+	t.Helper()
+
+	j, srv := newSession(t)
+	srv.Handle("load-configuration", warning+junostest.LoadSuccess)
+	srv.Handle("commit-configuration", "<commit-results>"+warning+"<routing-engine><name>re0</name><commit-success/></routing-engine></commit-results>")
+
+	return j, srv
+}
+
+func TestWarnings(t *testing.T) {
+	j, _ := newWarningSession(t)
+	ctx := context.Background()
+
+	res, err := j.ConfigWithResult(ctx, []string{"set interfaces ge-0/0/1"}, "set", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Warnings) != 1 || res.Warnings[0].BadElement != "ge-0/0/1" {
+		t.Errorf("got load warnings %+v", res.Warnings)
+	}
+
+	if res.Commit == nil || len(res.Commit.Warnings) != 1 {
+		t.Errorf("got commit result %+v", res.Commit)
+	}
+
+	if err := j.Commit(); err != nil {
+		t.Errorf("Commit returned %v for a warning", err)
+	}
+
+	if cr, err := j.CommitCheckWithResult(ctx); err != nil || len(cr.Warnings) != 1 {
+		t.Errorf("CommitCheckWithResult returned %+v, %v", cr, err)
+	}
+
+	if cr, err := j.CommitConfirmWithResult(ctx, 3); err != nil || len(cr.Warnings) != 1 {
+		t.Errorf("CommitConfirmWithResult returned %+v, %v", cr, err)
+	}
+}
+
+func TestFatalWarnings(t *testing.T) {
+	j, srv := newWarningSession(t)
+	j.WarningPolicy = junos.FatalWarnings
+
+	var rpcErr *junos.RPCError
+	if err := j.Config([]string{"set interfaces ge-0/0/1"}, "set", true); !errors.As(err, &rpcErr) || rpcErr.Severity != "warning" {
+		t.Errorf("Config returned %#v", err)
+	}
+
+	if n := srv.Received("commit-configuration"); n != 0 {
+		t.Error("the configuration was committed after a fatal warning")
+	}
+
+	if err := j.CommitCheck(); err == nil {
+		t.Error("CommitCheck ignored a fatal warning")
+	}
+}
+
+func TestWarningsIgnored(t *testing.T) {
+	j, srv := newSession(t)
+	srv.Handle("get-arp-table-information", warning+junostest.ArpTableInformation)
+
+	v, err := j.View("arp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v.Arp.Count != 1 {
+		t.Errorf("got ARP table %+v", v.Arp)
+	}
+}